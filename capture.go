@@ -0,0 +1,59 @@
+package tappay
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// CaptureParams defines the parameters for performing cap-today operation which captures
+// the transaction authorized with `delay_capture_in_days` immediately
+// More details in: https://docs.tappaysdk.com/tutorial/zh/advanced.html#cap-today-api
+type CaptureParams struct {
+	RecTradeID string `json:"rec_trade_id"`
+}
+
+// MarshalMap implements the Marshaler interface
+func (r CaptureParams) MarshalMap() (map[string]interface{}, error) {
+	p, err := json.Marshal(&r)
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal CaptureParams: %v, err: %v", r, err)
+	}
+	var m map[string]interface{}
+	if err = json.Unmarshal(p, &m); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal CaptureParams into map, err: %v", err)
+	}
+
+	return m, nil
+}
+
+// CaptureResponse defines the API response returns by TapPay server after cap-today request
+type CaptureResponse struct {
+	Status int    `json:"status"`
+	Msg    string `json:"msg"`
+}
+
+// capturePath defines the cap-today service path
+const capturePath = "/tpc/transaction/cap"
+
+// Capture issues a cap-today request according to input CaptureParams
+// and returns the parsed CaptureResponse from TapPay server
+func (c *client) Capture(ctx context.Context, params CaptureParams) (*CaptureResponse, error) {
+	req, err := c.newRequest(ctx, http.MethodPost, serviceCapture, params)
+	if err != nil {
+		return nil, err
+	}
+
+	rawResp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp CaptureResponse
+	if err = json.Unmarshal(rawResp, &resp); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal CaptureResponse, err: %v", err)
+	}
+
+	return &resp, c.checkStatus(serviceCapture, params.RecTradeID, rawResp)
+}
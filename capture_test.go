@@ -0,0 +1,43 @@
+package tappay
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCapture(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		recTradeID string
+		respBody   string
+		wantStatus int
+	}{
+		{
+			name:       "Given authorized rec_trade_id returns success",
+			recTradeID: "D20200101abc",
+			respBody:   `{"status":0,"msg":"Success"}`,
+			wantStatus: 0,
+		},
+		{
+			name:       "Given captured rec_trade_id returns error status",
+			recTradeID: "D20200101def",
+			respBody:   `{"status":10010,"msg":"Transaction already captured"}`,
+			wantStatus: 10010,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var params map[string]interface{}
+			cli := newTestClient(t, cannedResponse(t, capturePath, &params, tc.respBody))
+			resp, err := cli.Capture(context.Background(), CaptureParams{RecTradeID: tc.recTradeID})
+			if err != nil {
+				t.Fatalf("unexpected capture error, err: %v", err)
+			}
+			if params["rec_trade_id"] != tc.recTradeID {
+				t.Errorf("expected rec_trade_id: %s in request, got: %v", tc.recTradeID, params["rec_trade_id"])
+			}
+			if tc.wantStatus != resp.Status {
+				t.Errorf("expect status: %d, got %d", tc.wantStatus, resp.Status)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package tappay
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// bindCardPath defines the path of bind card service
+const bindCardPath = "/tpc/card/bind"
+
+// removeCardPath defines the path of remove card service
+const removeCardPath = "/tpc/card/remove"
+
+// BindCardParams defines the parameters for performing bind card operation which saves the card
+// with zero-amount verification instead of a real charge
+// More details in: https://docs.tappaysdk.com/tutorial/zh/advanced.html#bind-card-api
+type BindCardParams struct {
+	Prime             string                         `json:"prime"`
+	MerchantID        string                         `json:"merchant_id"`
+	Currency          Currency                       `json:"currency"`
+	Cardholder        PaymentParamsCardholder        `json:"cardholder"`
+	CardholderVerify  *PaymentParamsCardholderVerify `json:"cardholder_verify,omitempty"`
+	ThreeDomainSecure bool                           `json:"three_domain_secure,omitempty"`
+	ResultUrl         *PaymentParamsResultUrl        `json:"result_url,omitempty"`
+}
+
+// MarshalMap implements the Marshaler interface
+func (r BindCardParams) MarshalMap() (map[string]interface{}, error) {
+	p, err := json.Marshal(&r)
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal BindCardParams: %v, err: %v", r, err)
+	}
+	var m map[string]interface{}
+	if err = json.Unmarshal(p, &m); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal BindCardParams into map, err: %v", err)
+	}
+
+	return m, nil
+}
+
+// BindCardResponse defines the API response returns by TapPay server after bind card request
+type BindCardResponse struct {
+	Status              int                        `json:"status"`
+	Msg                 string                     `json:"msg"`
+	RecTradeID          string                     `json:"rec_trade_id"`
+	BankTransactionID   string                     `json:"bank_transaction_id"`
+	CardSecret          PaymentCardSecret          `json:"card_secret"`
+	CardInfo            PaymentCardInfo            `json:"card_info"`
+	CardIdentifier      string                     `json:"card_identifier"`
+	Currency            Currency                   `json:"currency"`
+	Acquirer            string                     `json:"acquirer"`
+	BankTransactionTime PaymentBankTransactionTime `json:"bank_transaction_time"`
+	BankResultCode      string                     `json:"bank_result_code"`
+	BankResultMsg       string                     `json:"bank_result_msg"`
+	PaymentUrl          string                     `json:"payment_url"`
+}
+
+// BindCard issues a bind card request according to input BindCardParams
+// and parses the response from TapPay server as BindCardResponse
+func (c *client) BindCard(ctx context.Context, params BindCardParams) (*BindCardResponse, error) {
+	req, err := c.newRequest(ctx, http.MethodPost, serviceBindCard, params)
+	if err != nil {
+		return nil, err
+	}
+
+	rawResp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp BindCardResponse
+	if err = json.Unmarshal(rawResp, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, c.checkStatus(serviceBindCard, "", rawResp)
+}
+
+// RemoveCardParams defines the parameters for performing remove card operation which revokes
+// the PaymentCardSecret so that it cannot be used in pay-by-token anymore
+// More details in: https://docs.tappaysdk.com/tutorial/zh/advanced.html#remove-card-api
+type RemoveCardParams struct {
+	CardKey   string `json:"card_key"`
+	CardToken string `json:"card_token"`
+}
+
+// MarshalMap implements the Marshaler interface
+func (r RemoveCardParams) MarshalMap() (map[string]interface{}, error) {
+	p, err := json.Marshal(&r)
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal RemoveCardParams: %v, err: %v", r, err)
+	}
+	var m map[string]interface{}
+	if err = json.Unmarshal(p, &m); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal RemoveCardParams into map, err: %v", err)
+	}
+
+	return m, nil
+}
+
+// RemoveCardResponse defines the API response returns by TapPay server after remove card request
+type RemoveCardResponse struct {
+	Status int    `json:"status"`
+	Msg    string `json:"msg"`
+}
+
+// RemoveCard issues a remove card request according to input RemoveCardParams
+// and parses the response from TapPay server as RemoveCardResponse
+func (c *client) RemoveCard(ctx context.Context, params RemoveCardParams) (*RemoveCardResponse, error) {
+	req, err := c.newRequest(ctx, http.MethodPost, serviceRemoveCard, params)
+	if err != nil {
+		return nil, err
+	}
+
+	rawResp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp RemoveCardResponse
+	if err = json.Unmarshal(rawResp, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, c.checkStatus(serviceRemoveCard, "", rawResp)
+}
@@ -0,0 +1,103 @@
+package tappay
+
+import (
+	"context"
+	"testing"
+)
+
+func TestBindCard(t *testing.T) {
+	for _, tc := range []struct {
+		name           string
+		params         BindCardParams
+		respBody       string
+		wantStatus     int
+		wantCardSecret PaymentCardSecret
+		wantIdentifier string
+	}{
+		{
+			name: "Given valid prime returns card secret",
+			params: BindCardParams{
+				Prime:      "test_3a2fb2b7e892b914a03c95dd4dd5dc7970c908df67a49527c0a648b2bc9",
+				MerchantID: "GlobalTesting_CTBC",
+				Currency:   "TWD",
+				Cardholder: PaymentParamsCardholder{
+					PhoneNumber: "0912345678",
+					Name:        "tappay-go",
+					Email:       "tappaygo@example.com",
+				},
+			},
+			respBody:       `{"status":0,"msg":"Success","card_secret":{"card_token":"token","card_key":"key"},"card_info":{"last_four":"4242","expiry_date":"202512"},"card_identifier":"identifier"}`,
+			wantStatus:     0,
+			wantCardSecret: PaymentCardSecret{CardToken: "token", CardKey: "key"},
+			wantIdentifier: "identifier",
+		},
+		{
+			name: "Given expired prime returns error status",
+			params: BindCardParams{
+				Prime:      "expired_prime",
+				MerchantID: "GlobalTesting_CTBC",
+				Currency:   "TWD",
+			},
+			respBody:   `{"status":91,"msg":"Prime expired"}`,
+			wantStatus: 91,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var params map[string]interface{}
+			cli := newTestClient(t, cannedResponse(t, bindCardPath, &params, tc.respBody))
+			resp, err := cli.BindCard(context.Background(), tc.params)
+			if err != nil {
+				t.Fatalf("unexpected bind card error, err: %v", err)
+			}
+			if params["prime"] != tc.params.Prime {
+				t.Errorf("expected prime: %s in request, got: %v", tc.params.Prime, params["prime"])
+			}
+			if tc.wantStatus != resp.Status {
+				t.Errorf("expected bind card status: %d, got :%d", tc.wantStatus, resp.Status)
+			}
+			if tc.wantCardSecret != resp.CardSecret {
+				t.Errorf("expected card secret: %+v, got :%+v", tc.wantCardSecret, resp.CardSecret)
+			}
+			if tc.wantIdentifier != resp.CardIdentifier {
+				t.Errorf("expected card identifier: %s, got :%s", tc.wantIdentifier, resp.CardIdentifier)
+			}
+		})
+	}
+}
+
+func TestRemoveCard(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		params     RemoveCardParams
+		respBody   string
+		wantStatus int
+	}{
+		{
+			name:       "Given bound card secret returns success",
+			params:     RemoveCardParams{CardKey: "key", CardToken: "token"},
+			respBody:   `{"status":0,"msg":"Success"}`,
+			wantStatus: 0,
+		},
+		{
+			name:       "Given removed card secret returns error status",
+			params:     RemoveCardParams{CardKey: "removed_key", CardToken: "removed_token"},
+			respBody:   `{"status":10003,"msg":"Card Error"}`,
+			wantStatus: 10003,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var params map[string]interface{}
+			cli := newTestClient(t, cannedResponse(t, removeCardPath, &params, tc.respBody))
+			resp, err := cli.RemoveCard(context.Background(), tc.params)
+			if err != nil {
+				t.Fatalf("unexpected remove card error, err: %v", err)
+			}
+			if params["card_key"] != tc.params.CardKey || params["card_token"] != tc.params.CardToken {
+				t.Errorf("expected card secret (%s, %s) in request, got: (%v, %v)", tc.params.CardKey, tc.params.CardToken, params["card_key"], params["card_token"])
+			}
+			if tc.wantStatus != resp.Status {
+				t.Errorf("expected remove card status: %d, got :%d", tc.wantStatus, resp.Status)
+			}
+		})
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"mime"
 	"net/http"
 	"net/url"
 	"os"
@@ -20,13 +22,22 @@ const (
 	APIURL string = "https://prod.tappaysdk.com/"
 )
 
+// defaultMaxResponseSize is the default maximum number of bytes read from the response body
+const defaultMaxResponseSize int64 = 10 << 20
+
 // type service denotes the the operations provided by TapPay
 type service string
 
 const (
-	servicePayByPrime service = "pay_by_prime"
-	serviceRecord     service = "record"
-	serviceRefund     service = "refund"
+	servicePayByPrime   service = "pay_by_prime"
+	servicePayByToken   service = "pay_by_token"
+	serviceRecord       service = "record"
+	serviceRefund       service = "refund"
+	serviceCapture      service = "capture"
+	serviceRefundCancel service = "refund_cancel"
+	serviceTradeHistory service = "trade_history"
+	serviceBindCard     service = "bind_card"
+	serviceRemoveCard   service = "remove_card"
 )
 
 type client struct {
@@ -35,6 +46,21 @@ type client struct {
 
 	// url is the base URL to use for API paths.
 	url string
+
+	// statusError denotes whether a non-zero status in response is returned as APIError
+	statusError bool
+
+	// maxResponseSize is the maximum number of bytes read from the response body
+	maxResponseSize int64
+
+	// skipValidation denotes whether the params are sent without validation
+	skipValidation bool
+
+	// resolveOutcome denotes whether the outcome of the payment failing in transport is resolved
+	// by querying the records every resolvePollInterval at first within resolveTimeout
+	resolveOutcome      bool
+	resolveTimeout      time.Duration
+	resolvePollInterval time.Duration
 }
 
 type clientOption func(*client)
@@ -55,9 +81,10 @@ func NewClient(key string, options ...clientOption) (*client, error) {
 	}
 
 	cli := &client{
-		partnerKey: key,
-		httpClient: httpClient,
-		url:        url,
+		partnerKey:      key,
+		httpClient:      httpClient,
+		url:             url,
+		maxResponseSize: defaultMaxResponseSize,
 	}
 
 	for _, option := range options {
@@ -93,7 +120,34 @@ func WithHTTPClient(hClient *http.Client) clientOption {
 	}
 }
 
-// do is used to issue the http request with client to TapPay server and parse the http.Response
+// WithStatusError returns a clientOption to return an APIError along with the decoded response
+// when TapPay server responds a non-zero status
+func WithStatusError() clientOption {
+	return func(c *client) {
+		c.statusError = true
+	}
+}
+
+// WithMaxResponseSize returns a clientOption to override the maximum number of bytes
+// read from the response body of TapPay server. The non-positive size is ignored.
+func WithMaxResponseSize(size int64) clientOption {
+	return func(c *client) {
+		if size > 0 {
+			c.maxResponseSize = size
+		}
+	}
+}
+
+// WithoutValidation returns a clientOption to send the params to TapPay server without validation
+func WithoutValidation() clientOption {
+	return func(c *client) {
+		c.skipValidation = true
+	}
+}
+
+// do is used to issue the http request with client to TapPay server and parse the http.Response.
+// It returns an HTTPError if the response is not a successful JSON response, and
+// ErrResponseTooLarge if the body of a successful JSON response exceeds the maximum response size of the client.
 func (c *client) do(req *http.Request) ([]byte, error) {
 	rawResp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -101,17 +155,46 @@ func (c *client) do(req *http.Request) ([]byte, error) {
 	}
 	defer rawResp.Body.Close()
 
+	if rawResp.StatusCode < 200 || rawResp.StatusCode > 299 || !isJSON(rawResp.Header.Get("Content-Type")) {
+		// keeps only the head of the body, e.g. a large error page of a gateway
+		body, err := ioutil.ReadAll(io.LimitReader(rawResp.Body, maxHTTPErrorBodySize))
+		if err != nil {
+			return nil, err
+		}
+		return nil, newHTTPError(rawResp, body)
+	}
+
 	var b []byte
 	buf := bytes.NewBuffer(b)
-	if _, err = io.Copy(buf, rawResp.Body); err != nil {
+	// reads one more byte than the limit to tell the body exceeding the limit apart
+	if _, err = io.Copy(buf, io.LimitReader(rawResp.Body, c.maxResponseSize+1)); err != nil {
 		return nil, err
 	}
+	if int64(buf.Len()) > c.maxResponseSize {
+		return nil, ErrResponseTooLarge
+	}
 	return buf.Bytes(), nil
 }
 
+// isJSON reports whether the media type of the content type is JSON
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json"
+}
+
 // newRequest is used to create the http request with the input. Also, appends the common header like
 // `content-type`, `x-api-key` and injects the common field `partner_key` into request body.
+// The input is validated first unless the client is created with WithoutValidation option.
 func (c *client) newRequest(ctx context.Context, method string, svc service, input Marshaler) (*http.Request, error) {
+	if v, ok := input.(validator); ok && !c.skipValidation {
+		if err := v.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
 	paramsMap, err := input.MarshalMap()
 	if err != nil {
 		return nil, err
@@ -121,10 +204,22 @@ func (c *client) newRequest(ctx context.Context, method string, svc service, inp
 	switch svc {
 	case servicePayByPrime:
 		svcPath = payByPrimePath
+	case servicePayByToken:
+		svcPath = payByTokenPath
 	case serviceRecord:
 		svcPath = recordPath
 	case serviceRefund:
 		svcPath = refundPath
+	case serviceCapture:
+		svcPath = capturePath
+	case serviceRefundCancel:
+		svcPath = refundCancelPath
+	case serviceTradeHistory:
+		svcPath = tradeHistoryPath
+	case serviceBindCard:
+		svcPath = bindCardPath
+	case serviceRemoveCard:
+		svcPath = removeCardPath
 	}
 
 	u, _ := url.Parse(svcPath)
@@ -1,7 +1,14 @@
 package tappay
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 )
 
@@ -71,3 +78,138 @@ func TestWithServer(t *testing.T) {
 	}
 	os.Setenv("TAPPAY_SERVER", originalTappayServer)
 }
+
+// newTestClient creates a client against a local server served by the handler
+func newTestClient(t *testing.T, handler http.Handler, options ...clientOption) *client {
+	t.Helper()
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	cli, err := NewClient("tappay_key", append([]clientOption{WithServer(srv.URL)}, options...)...)
+	if err != nil {
+		t.Fatalf("cannot create test client: %v", err)
+	}
+	return cli
+}
+
+// cannedResponse returns a handler which verifies the request path, stores the decoded
+// request body into params if it is not nil and replies the body as JSON
+func cannedResponse(t *testing.T, wantPath string, params *map[string]interface{}, body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != wantPath {
+			t.Errorf("expected request path: %s, got: %s", wantPath, r.URL.Path)
+		}
+		if params != nil {
+			if err := json.NewDecoder(r.Body).Decode(params); err != nil {
+				t.Errorf("cannot decode request body: %v", err)
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, body)
+	}
+}
+
+func TestDo(t *testing.T) {
+	for _, tc := range []struct {
+		name            string
+		statusCode      int
+		contentType     string
+		body            string
+		options         []clientOption
+		wantHTTPError   bool
+		wantBodyLen     int
+		wantTooLarge    bool
+		wantDecodedBody bool
+	}{
+		{
+			name:            "Given JSON response returns the body",
+			statusCode:      http.StatusOK,
+			contentType:     "application/json; charset=utf-8",
+			body:            `{"status":0}`,
+			wantDecodedBody: true,
+		},
+		{
+			name:          "Given bad gateway returns HTTPError",
+			statusCode:    http.StatusBadGateway,
+			contentType:   "text/html",
+			body:          "<html>502 Bad Gateway</html>",
+			wantHTTPError: true,
+			wantBodyLen:   len("<html>502 Bad Gateway</html>"),
+		},
+		{
+			name:          "Given non-JSON response with status OK returns HTTPError",
+			statusCode:    http.StatusOK,
+			contentType:   "text/html",
+			body:          "<html>maintenance</html>",
+			wantHTTPError: true,
+			wantBodyLen:   len("<html>maintenance</html>"),
+		},
+		{
+			name:          "Given large error page returns HTTPError with truncated body",
+			statusCode:    http.StatusServiceUnavailable,
+			contentType:   "text/html",
+			body:          strings.Repeat("x", 4096),
+			wantHTTPError: true,
+			wantBodyLen:   maxHTTPErrorBodySize,
+		},
+		{
+			name:          "Given error page exceeding maximum size returns HTTPError",
+			statusCode:    http.StatusBadGateway,
+			contentType:   "text/html",
+			body:          strings.Repeat("x", 4096),
+			options:       []clientOption{WithMaxResponseSize(32)},
+			wantHTTPError: true,
+			wantBodyLen:   maxHTTPErrorBodySize,
+		},
+		{
+			name:         "Given response exceeding maximum size returns ErrResponseTooLarge",
+			statusCode:   http.StatusOK,
+			contentType:  "application/json",
+			body:         `{"status":0,"msg":"` + strings.Repeat("x", 64) + `"}`,
+			options:      []clientOption{WithMaxResponseSize(32)},
+			wantTooLarge: true,
+		},
+		{
+			name:            "Given non-positive maximum size keeps the default size",
+			statusCode:      http.StatusOK,
+			contentType:     "application/json",
+			body:            `{"status":0,"msg":"Success"}`,
+			options:         []clientOption{WithMaxResponseSize(0), WithMaxResponseSize(-1)},
+			wantDecodedBody: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cli := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tc.contentType)
+				w.WriteHeader(tc.statusCode)
+				io.WriteString(w, tc.body)
+			}), tc.options...)
+			resp, err := cli.Records(context.Background(), RecordParams{})
+
+			var httpErr *HTTPError
+			switch {
+			case tc.wantHTTPError:
+				if !errors.As(err, &httpErr) {
+					t.Fatalf("expected HTTPError, got: %v", err)
+				}
+				if httpErr.StatusCode != tc.statusCode {
+					t.Errorf("expected status code: %d, got: %d", tc.statusCode, httpErr.StatusCode)
+				}
+				if httpErr.Header.Get("Content-Type") != tc.contentType {
+					t.Errorf("expected content type: %s, got: %s", tc.contentType, httpErr.Header.Get("Content-Type"))
+				}
+				if len(httpErr.Body) != tc.wantBodyLen {
+					t.Errorf("expected body length: %d, got: %d", tc.wantBodyLen, len(httpErr.Body))
+				}
+			case tc.wantTooLarge:
+				if err != ErrResponseTooLarge {
+					t.Errorf("expected ErrResponseTooLarge, got: %v", err)
+				}
+			case tc.wantDecodedBody:
+				if err != nil || resp == nil {
+					t.Errorf("expected decoded response, got error: %v", err)
+				}
+			}
+		})
+	}
+}
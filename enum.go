@@ -0,0 +1,157 @@
+package tappay
+
+import (
+	"fmt"
+)
+
+// Currency denotes the ISO 4217 currency code of the transaction
+type Currency string
+
+const (
+	CurrencyTWD Currency = "TWD"
+	CurrencyUSD Currency = "USD"
+	CurrencyJPY Currency = "JPY"
+)
+
+// String implements the fmt.Stringer interface
+func (c Currency) String() string {
+	return string(c)
+}
+
+// Valid reports whether the currency is supported by TapPay
+func (c Currency) Valid() bool {
+	switch c {
+	case CurrencyTWD, CurrencyUSD, CurrencyJPY:
+		return true
+	}
+	return false
+}
+
+// CardType denotes the `type` field of the card info, i.e. the card brand
+// It is encoded as the number in JSON, where the unknown values are preserved.
+type CardType int
+
+const (
+	CardTypeUnknown    CardType = -1
+	CardTypeVisa       CardType = 1
+	CardTypeMasterCard CardType = 2
+	CardTypeJCB        CardType = 3
+	CardTypeUnionPay   CardType = 4
+	CardTypeAMEX       CardType = 5
+)
+
+var cardTypeNames = map[int]string{
+	int(CardTypeUnknown):    "unknown",
+	int(CardTypeVisa):       "visa",
+	int(CardTypeMasterCard): "mastercard",
+	int(CardTypeJCB):        "jcb",
+	int(CardTypeUnionPay):   "unionpay",
+	int(CardTypeAMEX):       "amex",
+}
+
+// String implements the fmt.Stringer interface
+func (t CardType) String() string {
+	return enumName(int(t), cardTypeNames)
+}
+
+// Valid reports whether the card type is defined by TapPay
+func (t CardType) Valid() bool {
+	_, ok := cardTypeNames[int(t)]
+	return ok
+}
+
+// CardFunding denotes the `funding` field of the card info
+// It is encoded as the number in JSON, where the unknown values are preserved.
+type CardFunding int
+
+const (
+	CardFundingUnknown CardFunding = -1
+	CardFundingCredit  CardFunding = 0
+	CardFundingDebit   CardFunding = 1
+	CardFundingPrepaid CardFunding = 2
+)
+
+var cardFundingNames = map[int]string{
+	int(CardFundingUnknown): "unknown",
+	int(CardFundingCredit):  "credit",
+	int(CardFundingDebit):   "debit",
+	int(CardFundingPrepaid): "prepaid",
+}
+
+// String implements the fmt.Stringer interface
+func (f CardFunding) String() string {
+	return enumName(int(f), cardFundingNames)
+}
+
+// Valid reports whether the funding is defined by TapPay
+func (f CardFunding) Valid() bool {
+	_, ok := cardFundingNames[int(f)]
+	return ok
+}
+
+// EInvoiceCarrierType denotes the `type` field of the e-invoice carrier
+// It is encoded as the number in JSON, where the unknown values are preserved.
+type EInvoiceCarrierType int
+
+const (
+	EInvoiceCarrierTypeMobileBarcode  EInvoiceCarrierType = 0
+	EInvoiceCarrierTypeCitizenDigital EInvoiceCarrierType = 1
+	EInvoiceCarrierTypeMember         EInvoiceCarrierType = 2
+)
+
+var eInvoiceCarrierTypeNames = map[int]string{
+	int(EInvoiceCarrierTypeMobileBarcode):  "mobile_barcode",
+	int(EInvoiceCarrierTypeCitizenDigital): "citizen_digital_certificate",
+	int(EInvoiceCarrierTypeMember):         "member",
+}
+
+// String implements the fmt.Stringer interface
+func (t EInvoiceCarrierType) String() string {
+	return enumName(int(t), eInvoiceCarrierTypeNames)
+}
+
+// Valid reports whether the carrier type is defined by TapPay
+func (t EInvoiceCarrierType) Valid() bool {
+	_, ok := eInvoiceCarrierTypeNames[int(t)]
+	return ok
+}
+
+// PaymentMethod denotes the `payment_method` field of the record
+type PaymentMethod string
+
+const (
+	PaymentMethodCreditCard PaymentMethod = "credit_card"
+	PaymentMethodApplePay   PaymentMethod = "apple_pay"
+	PaymentMethodGooglePay  PaymentMethod = "google_pay"
+	PaymentMethodSamsungPay PaymentMethod = "samsung_pay"
+	PaymentMethodLinePay    PaymentMethod = "line_pay"
+	PaymentMethodJKOPay     PaymentMethod = "jko_pay"
+	PaymentMethodEasyWallet PaymentMethod = "easy_wallet"
+	PaymentMethodPiWallet   PaymentMethod = "pi_wallet"
+	PaymentMethodPlusPay    PaymentMethod = "plus_pay"
+	PaymentMethodAtome      PaymentMethod = "atome"
+)
+
+// String implements the fmt.Stringer interface
+func (m PaymentMethod) String() string {
+	return string(m)
+}
+
+// Valid reports whether the payment method is defined by TapPay
+func (m PaymentMethod) Valid() bool {
+	switch m {
+	case PaymentMethodCreditCard, PaymentMethodApplePay, PaymentMethodGooglePay, PaymentMethodSamsungPay,
+		PaymentMethodLinePay, PaymentMethodJKOPay, PaymentMethodEasyWallet, PaymentMethodPiWallet,
+		PaymentMethodPlusPay, PaymentMethodAtome:
+		return true
+	}
+	return false
+}
+
+// enumName returns the name of the enum value, or the number itself if it is unknown
+func enumName(v int, names map[int]string) string {
+	if name, ok := names[v]; ok {
+		return name
+	}
+	return fmt.Sprintf("%d", v)
+}
@@ -0,0 +1,82 @@
+package tappay
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRecordEnumsJSON(t *testing.T) {
+	raw := `{"record_status":1,"currency":"USD","payment_method":"line_pay","e_invoice_carrier":{"type":1},"card_info":{"funding":1,"type":5}}`
+	var r Record
+	if err := json.Unmarshal([]byte(raw), &r); err != nil {
+		t.Fatalf("unexpected unmarshal error: %v", err)
+	}
+	if r.RecordStatus != RecordStatusOK || r.RecordStatus.String() != "ok" {
+		t.Errorf("expected record status ok, got: %v", r.RecordStatus)
+	}
+	if r.Currency != CurrencyUSD || r.PaymentMethod != PaymentMethodLinePay {
+		t.Errorf("expected USD paid by line_pay, got: %v, %v", r.Currency, r.PaymentMethod)
+	}
+	if r.CardInfo.Funding != CardFundingDebit || r.CardInfo.Type != CardTypeAMEX {
+		t.Errorf("expected debit AMEX card, got: %v %v", r.CardInfo.Funding, r.CardInfo.Type)
+	}
+	if r.EInvoiceCarrier.Type != EInvoiceCarrierTypeCitizenDigital {
+		t.Errorf("expected citizen digital certificate carrier, got: %v", r.EInvoiceCarrier.Type)
+	}
+}
+
+func TestEnumUnknownValues(t *testing.T) {
+	var status RecordStatus
+	if err := json.Unmarshal([]byte(`42`), &status); err != nil {
+		t.Fatalf("unexpected unmarshal error: %v", err)
+	}
+	if status.Valid() || status.String() != "42" {
+		t.Errorf("expected unknown record status 42, got: %v, valid: %t", status, status.Valid())
+	}
+	if b, _ := json.Marshal(status); string(b) != "42" {
+		t.Errorf("expected unknown record status marshaled as 42, got: %s", b)
+	}
+
+	var resp RecordResponse
+	raw := `{"status":0,"trade_records":[{"record_status":9,"card_info":{"funding":7,"type":8},"e_invoice_carrier":{"type":6}},{"record_status":1}]}`
+	if err := json.Unmarshal([]byte(raw), &resp); err != nil {
+		t.Fatalf("unexpected unmarshal error of unknown values: %v", err)
+	}
+	r := resp.TradeRecords[0]
+	if r.RecordStatus != 9 || r.CardInfo.Funding != 7 || r.CardInfo.Type != 8 || r.EInvoiceCarrier.Type != 6 {
+		t.Errorf("expected unknown values preserved, got: %+v", r)
+	}
+	if r.CardInfo.Type.String() != "8" || resp.TradeRecords[1].RecordStatus != RecordStatusOK {
+		t.Errorf("unexpected records: %+v", resp.TradeRecords)
+	}
+
+	if Currency("EUR").Valid() || !CurrencyJPY.Valid() {
+		t.Errorf("unexpected currency validation")
+	}
+	if PaymentMethod("bitcoin").Valid() || !PaymentMethodApplePay.Valid() {
+		t.Errorf("unexpected payment method validation")
+	}
+}
+
+func TestRecordFiltersRecordStatus(t *testing.T) {
+	auth, refunded := RecordStatusAuth, RecordStatusRefunded
+	for _, tc := range []struct {
+		name    string
+		filters RecordFilters
+		want    string
+	}{
+		{name: "Given no record status omits the filter", want: `{}`},
+		{name: "Given auth record status marshals zero", filters: RecordFilters{RecordStatus: &auth}, want: `{"record_status":0}`},
+		{name: "Given refunded record status marshals the number", filters: RecordFilters{RecordStatus: &refunded}, want: `{"record_status":3}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			b, err := json.Marshal(tc.filters)
+			if err != nil {
+				t.Fatalf("unexpected marshal error: %v", err)
+			}
+			if string(b) != tc.want {
+				t.Errorf("expected: %s, got: %s", tc.want, b)
+			}
+		})
+	}
+}
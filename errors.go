@@ -0,0 +1,107 @@
+package tappay
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrResponseTooLarge is returned when the response body of TapPay server exceeds
+// the maximum response size of the client. See WithMaxResponseSize.
+var ErrResponseTooLarge = errors.New("tappay: response body exceeds the maximum response size")
+
+// maxHTTPErrorBodySize is the maximum number of bytes of the response body kept in HTTPError
+const maxHTTPErrorBodySize = 1024
+
+// HTTPError is the error returned when TapPay server, or any proxy in between, responds
+// a non-2xx status code or a body which is not JSON
+type HTTPError struct {
+	StatusCode int
+	Header     http.Header
+
+	// Body is the response body truncated to at most 1024 bytes
+	Body []byte
+}
+
+// Error implements the error interface
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("tappay: unexpected http response %d (%s): %q", e.StatusCode, e.Header.Get("Content-Type"), e.Body)
+}
+
+// newHTTPError creates the HTTPError from the response and its body, which is read up to maxHTTPErrorBodySize
+func newHTTPError(resp *http.Response, body []byte) *HTTPError {
+	return &HTTPError{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       body,
+	}
+}
+
+// APIError is the error returned along with the decoded response when TapPay server responds
+// a non-zero status and the client is created with WithStatusError option.
+// The underlying error can be retrieved with errors.As
+//
+//	var apiErr *tappay.APIError
+//	if errors.As(err, &apiErr) { ... }
+type APIError struct {
+	Status         int
+	Msg            string
+	BankResultCode string
+	BankResultMsg  string
+	RecTradeID     string
+
+	// Service is the name of the operation which responds the error, e.g. pay_by_prime
+	Service string
+}
+
+// Error implements the error interface
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("tappay: %s responds status %d: %s", e.Service, e.Status, e.Msg)
+	if e.BankResultCode != "" || e.BankResultMsg != "" {
+		msg += fmt.Sprintf(" (bank result: %s %s)", e.BankResultCode, e.BankResultMsg)
+	}
+	if e.RecTradeID != "" {
+		msg += fmt.Sprintf(", rec_trade_id: %s", e.RecTradeID)
+	}
+	return msg
+}
+
+// apiStatus defines the fields shared by the API responses which are used to build the APIError
+type apiStatus struct {
+	Status         int    `json:"status"`
+	Msg            string `json:"msg"`
+	BankResultCode string `json:"bank_result_code"`
+	BankResultMsg  string `json:"bank_result_msg"`
+	RecTradeID     string `json:"rec_trade_id"`
+}
+
+// checkStatus returns an APIError if the client is created with WithStatusError option
+// and the raw response of the svc denotes a failure. The recTradeID is used in the APIError
+// when the response itself does not carry the field.
+func (c *client) checkStatus(svc service, recTradeID string, rawResp []byte) error {
+	if !c.statusError {
+		return nil
+	}
+
+	var s apiStatus
+	if err := json.Unmarshal(rawResp, &s); err != nil {
+		return fmt.Errorf("cannot unmarshal the status of %s response, err: %v", svc, err)
+	}
+	status := StatusCode(s.Status)
+	if status == StatusSuccess || (svc == serviceRecord && status == StatusNoRecord) {
+		return nil
+	}
+	if s.RecTradeID == "" {
+		s.RecTradeID = recTradeID
+	}
+
+	return &APIError{
+		Status:         s.Status,
+		Msg:            s.Msg,
+		BankResultCode: s.BankResultCode,
+		BankResultMsg:  s.BankResultMsg,
+		RecTradeID:     s.RecTradeID,
+		Service:        string(svc),
+	}
+}
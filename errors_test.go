@@ -0,0 +1,69 @@
+package tappay
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestStatusError(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		options      []clientOption
+		respBody     string
+		wantAPIError *APIError
+	}{
+		{
+			name:     "Given non-zero status without option returns no error",
+			respBody: `{"status":11000,"msg":"Invalid rec_trade_id"}`,
+		},
+		{
+			name:     "Given zero status with option returns no error",
+			options:  []clientOption{WithStatusError()},
+			respBody: `{"status":0,"msg":"Success"}`,
+		},
+		{
+			name:     "Given non-zero status with option returns APIError",
+			options:  []clientOption{WithStatusError()},
+			respBody: `{"status":11000,"msg":"Invalid rec_trade_id","bank_result_code":"","bank_result_msg":""}`,
+			wantAPIError: &APIError{
+				Status:     11000,
+				Msg:        "Invalid rec_trade_id",
+				RecTradeID: "Invalid_trade_id",
+				Service:    string(serviceRefund),
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cli := newTestClient(t, cannedResponse(t, refundPath, nil, tc.respBody), tc.options...)
+			resp, err := cli.Refund(context.Background(), RefundParams{RecTradeID: "Invalid_trade_id"})
+			if resp == nil {
+				t.Fatalf("expected the decoded response, got nil with error: %v", err)
+			}
+
+			var apiErr *APIError
+			if tc.wantAPIError == nil {
+				if err != nil {
+					t.Errorf("expected no error, got: %v", err)
+				}
+				return
+			}
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("expected APIError, got: %v", err)
+			}
+			if *tc.wantAPIError != *apiErr {
+				t.Errorf("expected APIError: %+v, got: %+v", tc.wantAPIError, apiErr)
+			}
+			if resp.Status != apiErr.Status {
+				t.Errorf("expected response status: %d, got: %d", apiErr.Status, resp.Status)
+			}
+		})
+	}
+}
+
+func TestStatusErrorRecordsNoData(t *testing.T) {
+	cli := newTestClient(t, cannedResponse(t, recordPath, nil, `{"status":2,"msg":"No data","trade_records":[]}`), WithStatusError())
+	if _, err := cli.Records(context.Background(), RecordParams{}); err != nil {
+		t.Errorf("expected no error for empty records, got: %v", err)
+	}
+}
@@ -0,0 +1,234 @@
+// Package export writes TapPay trade records into CSV, NDJSON and Excel-friendly CSV formats.
+package export
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/babygoat/tappay-go"
+)
+
+// utf8BOM is the byte order mark which makes Excel open the CSV as UTF-8
+const utf8BOM = "\ufeff"
+
+// excelTimeFormat is the time format recognized by Excel as date and time
+const excelTimeFormat = "2006-01-02 15:04:05"
+
+// DefaultColumns are the columns written when Options.Columns is empty
+var DefaultColumns = []string{
+	"RecTradeID",
+	"OrderNumber",
+	"BankTransactionID",
+	"MerchantID",
+	"Time",
+	"Amount",
+	"RefundedAmount",
+	"Currency",
+	"RecordStatus",
+	"IsCaptured",
+	"CapMillis",
+	"PaymentMethod",
+	"CardInfo.LastFour",
+	"Details",
+}
+
+// millisColumns are the columns of unix time in milliseconds, which are written as formatted time
+var millisColumns = map[string]bool{
+	"Time":                       true,
+	"CapMillis":                  true,
+	"BankTransactionStartMillis": true,
+	"BankTransactionEndMillis":   true,
+}
+
+// sliceSource implements tappay.RecordSource over a slice of records
+type sliceSource struct {
+	records []tappay.Record
+	record  tappay.Record
+}
+
+// Records returns a tappay.RecordSource iterating over the records
+func Records(records []tappay.Record) tappay.RecordSource {
+	return &sliceSource{records: records}
+}
+
+func (s *sliceSource) Next(ctx context.Context) bool {
+	if len(s.records) == 0 || ctx.Err() != nil {
+		return false
+	}
+	s.record, s.records = s.records[0], s.records[1:]
+	return true
+}
+
+func (s *sliceSource) Record() tappay.Record {
+	return s.record
+}
+
+func (s *sliceSource) Err() error {
+	return nil
+}
+
+// Options defines the options of the CSV exporters
+type Options struct {
+	// Columns are the dot-separated paths of the Record fields to be written, e.g. `CardInfo.LastFour`.
+	// A struct or slice field, e.g. `InstalmentInfo`, is written as JSON. Defaults to DefaultColumns.
+	Columns []string
+
+	// Location is the time zone of the timestamps. Defaults to UTC in WriteCSV and Asia/Taipei in WriteExcelCSV.
+	Location *time.Location
+
+	// TimeFormat is the layout of the timestamps. Defaults to time.RFC3339 in WriteCSV and
+	// `2006-01-02 15:04:05` in WriteExcelCSV.
+	TimeFormat string
+}
+
+// WriteCSV writes the records from src as CSV with a header row into w
+func WriteCSV(ctx context.Context, w io.Writer, src tappay.RecordSource, opts Options) error {
+	if opts.Location == nil {
+		opts.Location = time.UTC
+	}
+	if opts.TimeFormat == "" {
+		opts.TimeFormat = time.RFC3339
+	}
+	return writeCSV(ctx, w, src, opts, false)
+}
+
+// WriteExcelCSV writes the records from src as CSV with UTF-8 byte order mark into w, with the
+// timestamps in Taiwan time zone by default, so that the file can be opened by Excel directly.
+// The text cells starting with `=`, `+`, `-` or `@` are prefixed with `'` so that they are not run as formulas.
+func WriteExcelCSV(ctx context.Context, w io.Writer, src tappay.RecordSource, opts Options) error {
+	if opts.Location == nil {
+		opts.Location = tappay.TaipeiLocation
+	}
+	if opts.TimeFormat == "" {
+		opts.TimeFormat = excelTimeFormat
+	}
+	if _, err := io.WriteString(w, utf8BOM); err != nil {
+		return err
+	}
+	return writeCSV(ctx, w, src, opts, true)
+}
+
+// WriteNDJSON writes the records from src as newline delimited JSON into w
+func WriteNDJSON(ctx context.Context, w io.Writer, src tappay.RecordSource) error {
+	enc := json.NewEncoder(w)
+	for src.Next(ctx) {
+		if err := enc.Encode(src.Record()); err != nil {
+			return err
+		}
+	}
+	if err := src.Err(); err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+// writeCSV writes the records from src as CSV, escaping the text cells which are formulas if escapeFormulas is set
+func writeCSV(ctx context.Context, w io.Writer, src tappay.RecordSource, opts Options, escapeFormulas bool) error {
+	columns := opts.Columns
+	if len(columns) == 0 {
+		columns = DefaultColumns
+	}
+	fields := make([][]int, len(columns))
+	for i, column := range columns {
+		index, err := fieldIndex(column)
+		if err != nil {
+			return err
+		}
+		fields[i] = index
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(columns); err != nil {
+		return err
+	}
+	row := make([]string, len(columns))
+	for src.Next(ctx) {
+		v := reflect.ValueOf(src.Record())
+		for i, column := range columns {
+			field := v.FieldByIndex(fields[i])
+			if millisColumns[column] {
+				row[i] = formatMillis(field.Int(), opts)
+				continue
+			}
+			s, err := formatValue(field)
+			if err != nil {
+				return fmt.Errorf("cannot format column %s, err: %v", column, err)
+			}
+			if escapeFormulas && field.Kind() == reflect.String {
+				s = escapeFormula(s)
+			}
+			row[i] = s
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return err
+	}
+	if err := src.Err(); err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+// fieldIndex returns the index sequence of the field at the dot-separated path of tappay.Record
+func fieldIndex(path string) ([]int, error) {
+	t := reflect.TypeOf(tappay.Record{})
+	var index []int
+	for _, name := range strings.Split(path, ".") {
+		if t.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("unknown column %s: %s is not a struct", path, t)
+		}
+		f, ok := t.FieldByName(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown column %s", path)
+		}
+		index = append(index, f.Index...)
+		t = f.Type
+	}
+	return index, nil
+}
+
+// formatMillis formats the unix time in milliseconds, leaving zero time empty
+func formatMillis(ms int64, opts Options) string {
+	if ms == 0 {
+		return ""
+	}
+	return time.Unix(0, ms*int64(time.Millisecond)).In(opts.Location).Format(opts.TimeFormat)
+}
+
+// formatValue formats the field value as a CSV cell
+func formatValue(v reflect.Value) (string, error) {
+	if s, ok := v.Interface().(fmt.Stringer); ok {
+		return s.String(), nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	default:
+		b, err := json.Marshal(v.Interface())
+		return string(b), err
+	}
+}
+
+// escapeFormula prefixes the cell starting with the formula characters with `'`,
+// which makes spreadsheet applications treat the cell as text
+func escapeFormula(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
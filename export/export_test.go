@@ -0,0 +1,161 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/babygoat/tappay-go"
+)
+
+var testRecords = []tappay.Record{
+	{
+		RecTradeID:   "D0001",
+		OrderNumber:  "order-1",
+		Time:         time.Date(2020, 1, 1, 16, 30, 0, 0, time.UTC).UnixNano() / int64(time.Millisecond),
+		Amount:       100,
+		Currency:     "TWD",
+		RecordStatus: tappay.RecordStatusOK,
+		Details:      "茶葉, 2 boxes",
+		CardInfo:     tappay.RecordCardInfo{LastFour: "4242"},
+		InstalmentInfo: tappay.RecordInstalmentInfo{
+			NumberOfInstalments: 3,
+			FirstPayment:        34,
+			EachPayment:         33,
+		},
+	},
+	{
+		RecTradeID:  "D0002",
+		OrderNumber: "order-2",
+		Amount:      50,
+		Currency:    "TWD",
+	},
+}
+
+// formulaRecords are the records with the text fields which are formulas in spreadsheet applications
+var formulaRecords = []tappay.Record{
+	{
+		OrderNumber: `=HYPERLINK("http://evil")`,
+		Details:     "+1",
+		Amount:      -5,
+		Cardholder:  tappay.RecordCardholder{Name: "@SUM(A1)"},
+	},
+	{
+		OrderNumber: "order-3",
+		Details:     "-2 boxes",
+		Cardholder:  tappay.RecordCardholder{Name: "tappay-go"},
+	},
+}
+
+func TestWriteCSV(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		write     func(w *bytes.Buffer, opts Options) error
+		opts      Options
+		want      string
+		wantError bool
+	}{
+		{
+			name: "Given nested columns writes CSV in UTC",
+			write: func(w *bytes.Buffer, opts Options) error {
+				return WriteCSV(context.Background(), w, Records(testRecords), opts)
+			},
+			opts: Options{Columns: []string{"RecTradeID", "Time", "Details", "CardInfo.LastFour", "InstalmentInfo"}},
+			want: "RecTradeID,Time,Details,CardInfo.LastFour,InstalmentInfo\n" +
+				`D0001,2020-01-01T16:30:00Z,"茶葉, 2 boxes",4242,"{""number_of_instalments"":3,""first_payment"":34,""each_payment"":33}"` + "\n" +
+				`D0002,,,,"{""number_of_instalments"":0,""first_payment"":0,""each_payment"":0}"` + "\n",
+		},
+		{
+			name: "Given Excel CSV writes BOM and timestamps in Taiwan time",
+			write: func(w *bytes.Buffer, opts Options) error {
+				return WriteExcelCSV(context.Background(), w, Records(testRecords), opts)
+			},
+			opts: Options{Columns: []string{"OrderNumber", "Time", "Amount"}},
+			want: "\ufeffOrderNumber,Time,Amount\n" +
+				"order-1,2020-01-02 00:30:00,100\n" +
+				"order-2,,50\n",
+		},
+		{
+			name: "Given Excel CSV escapes the text cells of formulas",
+			write: func(w *bytes.Buffer, opts Options) error {
+				return WriteExcelCSV(context.Background(), w, Records(formulaRecords), opts)
+			},
+			opts: Options{Columns: []string{"OrderNumber", "Details", "Cardholder.Name", "Amount"}},
+			want: "\ufeffOrderNumber,Details,Cardholder.Name,Amount\n" +
+				"\"'=HYPERLINK(\"\"http://evil\"\")\",'+1,'@SUM(A1),-5\n" +
+				"order-3,'-2 boxes,tappay-go,0\n",
+		},
+		{
+			name: "Given plain CSV writes the text cells as is",
+			write: func(w *bytes.Buffer, opts Options) error {
+				return WriteCSV(context.Background(), w, Records(formulaRecords), opts)
+			},
+			opts: Options{Columns: []string{"OrderNumber", "Details"}},
+			want: "OrderNumber,Details\n" +
+				"\"=HYPERLINK(\"\"http://evil\"\")\",+1\n" +
+				"order-3,-2 boxes\n",
+		},
+		{
+			name: "Given unknown column returns error",
+			write: func(w *bytes.Buffer, opts Options) error {
+				return WriteCSV(context.Background(), w, Records(testRecords), opts)
+			},
+			opts:      Options{Columns: []string{"CardInfo.Unknown"}},
+			wantError: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := tc.write(&buf, tc.opts)
+			if tc.wantError {
+				if err == nil {
+					t.Errorf("expected error, got output: %s", buf.String())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected export error: %v", err)
+			}
+			if buf.String() != tc.want {
+				t.Errorf("expected output:\n%s\ngot:\n%s", tc.want, buf.String())
+			}
+		})
+	}
+}
+
+func TestWriteCSVDefaultColumns(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteCSV(context.Background(), &buf, Records(testRecords), Options{}); err != nil {
+		t.Fatalf("unexpected export error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != len(testRecords)+1 {
+		t.Fatalf("expected header and %d rows, got: %d lines", len(testRecords), len(lines))
+	}
+	if lines[0] != strings.Join(DefaultColumns, ",") {
+		t.Errorf("expected default columns header, got: %s", lines[0])
+	}
+}
+
+func TestWriteNDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteNDJSON(context.Background(), &buf, Records(testRecords)); err != nil {
+		t.Fatalf("unexpected export error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != len(testRecords) {
+		t.Fatalf("expected %d lines, got: %d", len(testRecords), len(lines))
+	}
+	for i, line := range lines {
+		var r tappay.Record
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatalf("cannot unmarshal line %d: %v", i, err)
+		}
+		if r.RecTradeID != testRecords[i].RecTradeID {
+			t.Errorf("expected rec_trade_id: %s, got: %s", testRecords[i].RecTradeID, r.RecTradeID)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package tappay
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// TradeHistoryAction denotes the action performed on a transaction in its trade history
+type TradeHistoryAction int
+
+const (
+	TradeHistoryActionAuth          TradeHistoryAction = 0
+	TradeHistoryActionCapture       TradeHistoryAction = 1
+	TradeHistoryActionRefund        TradeHistoryAction = 3
+	TradeHistoryActionPending       TradeHistoryAction = 4
+	TradeHistoryActionCancelRefund  TradeHistoryAction = 5
+	TradeHistoryActionCancelCapture TradeHistoryAction = 6
+)
+
+// tradeHistoryPath defines the path of trade history service
+const tradeHistoryPath = "/tpc/transaction/trade-history"
+
+// tradeHistoryParams defines the params for performing trade history query operation
+type tradeHistoryParams struct {
+	RecTradeID string `json:"rec_trade_id"`
+}
+
+// MarshalMap implements the Marshaler interface
+func (r tradeHistoryParams) MarshalMap() (map[string]interface{}, error) {
+	p, err := json.Marshal(&r)
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal tradeHistoryParams: %v, err: %v", r, err)
+	}
+	var m map[string]interface{}
+	if err = json.Unmarshal(p, &m); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal tradeHistoryParams into map, err: %v", err)
+	}
+
+	return m, nil
+}
+
+// TradeHistory defines a single action in the trade history of a transaction
+// See TradeHistoryResponse for more details.
+type TradeHistory struct {
+	Action         TradeHistoryAction `json:"action"`
+	Amount         int                `json:"amount"`
+	Millis         int64              `json:"millis"`
+	Success        bool               `json:"success"`
+	IsPending      bool               `json:"is_pending"`
+	BankResultCode string             `json:"bank_result_code"`
+	BankResultMsg  string             `json:"bank_result_msg"`
+}
+
+// TradeHistoryResponse defines the API response returns from TapPay server after trade history query is issued
+// More details in: https://docs.tappaysdk.com/tutorial/zh/back.html#trade-history-api
+type TradeHistoryResponse struct {
+	Status       int            `json:"status"`
+	Msg          string         `json:"msg"`
+	Currency     Currency       `json:"currency"`
+	TradeHistory []TradeHistory `json:"trade_history"`
+}
+
+// TradeHistory issues a trade history query of the transaction identified by recTradeID
+// and parse the response from TapPay server as TradeHistoryResponse
+func (c *client) TradeHistory(ctx context.Context, recTradeID string) (*TradeHistoryResponse, error) {
+	req, err := c.newRequest(ctx, http.MethodPost, serviceTradeHistory, tradeHistoryParams{RecTradeID: recTradeID})
+	if err != nil {
+		return nil, err
+	}
+
+	rawResp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp TradeHistoryResponse
+	if err = json.Unmarshal(rawResp, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, c.checkStatus(serviceTradeHistory, recTradeID, rawResp)
+}
@@ -0,0 +1,56 @@
+package tappay
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestTradeHistory(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		recTradeID  string
+		respBody    string
+		wantStatus  int
+		wantHistory []TradeHistory
+	}{
+		{
+			name:       "Given refunded rec_trade_id returns auth, capture and refund actions",
+			recTradeID: "D20200101abc",
+			respBody: `{"status":0,"msg":"Success","currency":"TWD","trade_history":[
+				{"action":0,"amount":100,"millis":1577836800000,"success":true,"bank_result_code":"00"},
+				{"action":1,"amount":100,"millis":1577836900000,"success":true},
+				{"action":3,"amount":40,"millis":1577837000000,"success":true}]}`,
+			wantStatus: 0,
+			wantHistory: []TradeHistory{
+				{Action: TradeHistoryActionAuth, Amount: 100, Millis: 1577836800000, Success: true, BankResultCode: "00"},
+				{Action: TradeHistoryActionCapture, Amount: 100, Millis: 1577836900000, Success: true},
+				{Action: TradeHistoryActionRefund, Amount: 40, Millis: 1577837000000, Success: true},
+			},
+		},
+		{
+			name:       "Given invalid rec_trade_id returns error status",
+			recTradeID: "Invalid_trade_id",
+			respBody:   `{"status":11000,"msg":"Invalid rec_trade_id"}`,
+			wantStatus: 11000,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var params map[string]interface{}
+			cli := newTestClient(t, cannedResponse(t, tradeHistoryPath, &params, tc.respBody))
+			resp, err := cli.TradeHistory(context.Background(), tc.recTradeID)
+			if err != nil {
+				t.Fatalf("unexpected trade history error: %v", err)
+			}
+			if params["rec_trade_id"] != tc.recTradeID {
+				t.Errorf("expected rec_trade_id: %s in request, got: %v", tc.recTradeID, params["rec_trade_id"])
+			}
+			if tc.wantStatus != resp.Status {
+				t.Errorf("expected status: %d, got: %d", tc.wantStatus, resp.Status)
+			}
+			if !reflect.DeepEqual(tc.wantHistory, resp.TradeHistory) {
+				t.Errorf("expected trade history: %+v, got: %+v", tc.wantHistory, resp.TradeHistory)
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package tappay
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is the currency used by TapPay when the currency is not specified
+const DefaultCurrency = CurrencyTWD
+
+// currencyExponents defines the number of digits of the minor unit of the currencies used by TapPay.
+// TWD is treated as having no minor unit since TapPay does not accept fractional NT dollars.
+var currencyExponents = map[Currency]int{
+	CurrencyTWD: 0,
+	CurrencyJPY: 0,
+	CurrencyUSD: 2,
+}
+
+// ErrCurrencyMismatch is returned by the arithmetic of Money of different currencies
+var ErrCurrencyMismatch = errors.New("tappay: currency mismatch")
+
+// Money defines an amount of the ISO 4217 currency. The Amount is in the minor unit of the currency,
+// i.e. cents for USD and yen for JPY, which is the unit of the amount fields in TapPay API.
+type Money struct {
+	Amount   int64
+	Currency Currency
+}
+
+// NewMoney returns the Money of the amount in the minor unit of the currency.
+// The currency defaults to DefaultCurrency if it is empty.
+func NewMoney(amount int64, currency Currency) Money {
+	return Money{Amount: amount, Currency: normalizeCurrency(currency)}
+}
+
+// ParseMoney parses the decimal amount in the major unit of the currency, e.g. `10.50` USD,
+// into Money. It returns an error if the amount has more fractional digits than the currency allows.
+func ParseMoney(amount string, currency Currency) (Money, error) {
+	m := NewMoney(0, currency)
+	exp := CurrencyExponent(m.Currency)
+
+	s := amount
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+	parts := strings.SplitN(s, ".", 2)
+	frac := ""
+	if len(parts) == 2 {
+		frac = parts[1]
+	}
+	if parts[0] == "" || len(frac) > exp || (len(parts) == 2 && frac == "") {
+		return Money{}, fmt.Errorf("tappay: invalid amount %q of %s", amount, m.Currency)
+	}
+	frac += strings.Repeat("0", exp-len(frac))
+	v, err := strconv.ParseInt(parts[0]+frac, 10, 64)
+	if err != nil || strings.ContainsAny(parts[0]+frac, "+-") {
+		return Money{}, fmt.Errorf("tappay: invalid amount %q of %s", amount, m.Currency)
+	}
+	if negative {
+		v = -v
+	}
+	m.Amount = v
+	return m, nil
+}
+
+// CurrencyExponent returns the number of digits of the minor unit of the currency.
+// Unknown currencies are assumed to have 2 digits as most of ISO 4217 currencies do.
+func CurrencyExponent(currency Currency) int {
+	if exp, ok := currencyExponents[normalizeCurrency(currency)]; ok {
+		return exp
+	}
+	return 2
+}
+
+// Decimal returns the amount in the major unit of the currency, e.g. `10.50` for 1050 USD cents
+func (m Money) Decimal() string {
+	exp := CurrencyExponent(m.Currency)
+	v := m.Amount
+	sign := ""
+	if v < 0 {
+		sign, v = "-", -v
+	}
+	s := strconv.FormatInt(v, 10)
+	if exp == 0 {
+		return sign + s
+	}
+	if len(s) <= exp {
+		s = strings.Repeat("0", exp-len(s)+1) + s
+	}
+	return sign + s[:len(s)-exp] + "." + s[len(s)-exp:]
+}
+
+// String implements the fmt.Stringer interface, e.g. `USD 10.50`
+func (m Money) String() string {
+	return string(m.currency()) + " " + m.Decimal()
+}
+
+// IsZero reports whether the amount is zero
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// Add returns the sum of m and o, or ErrCurrencyMismatch if they are of different currencies
+func (m Money) Add(o Money) (Money, error) {
+	if m.currency() != o.currency() {
+		return Money{}, ErrCurrencyMismatch
+	}
+	return Money{Amount: m.Amount + o.Amount, Currency: m.currency()}, nil
+}
+
+// Sub returns the difference of m and o, or ErrCurrencyMismatch if they are of different currencies
+func (m Money) Sub(o Money) (Money, error) {
+	if m.currency() != o.currency() {
+		return Money{}, ErrCurrencyMismatch
+	}
+	return Money{Amount: m.Amount - o.Amount, Currency: m.currency()}, nil
+}
+
+// Cmp compares m and o and returns -1, 0 or +1 if m is less than, equal to or greater than o,
+// or ErrCurrencyMismatch if they are of different currencies
+func (m Money) Cmp(o Money) (int, error) {
+	if m.currency() != o.currency() {
+		return 0, ErrCurrencyMismatch
+	}
+	switch {
+	case m.Amount < o.Amount:
+		return -1, nil
+	case m.Amount > o.Amount:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// currency returns the currency of m, which defaults to DefaultCurrency
+func (m Money) currency() Currency {
+	return normalizeCurrency(m.Currency)
+}
+
+// normalizeCurrency returns the currency in upper case, which defaults to DefaultCurrency
+func normalizeCurrency(currency Currency) Currency {
+	if currency == "" {
+		return DefaultCurrency
+	}
+	return Currency(strings.ToUpper(string(currency)))
+}
+
+// SetMoney sets the amount and currency of the payment
+func (r *PaymentPrimeParams) SetMoney(m Money) {
+	r.Amount = int(m.Amount)
+	r.Currency = m.currency()
+}
+
+// SetMoney sets the amount and currency of the payment
+func (r *PaymentTokenParams) SetMoney(m Money) {
+	r.Amount = int(m.Amount)
+	r.Currency = m.currency()
+}
+
+// SetMoney sets the amount of the partial refund. The currency is the one of the refunded transaction.
+func (r *RefundParams) SetMoney(m Money) {
+	r.Amount = strconv.FormatInt(m.Amount, 10)
+}
+
+// Money returns the amount and currency of the payment
+func (r PaymentResponse) Money() Money {
+	return NewMoney(int64(r.Amount), r.Currency)
+}
+
+// RefundMoney returns the refunded amount and currency
+func (r RefundResponse) RefundMoney() Money {
+	return NewMoney(int64(r.RefundAmount), r.Currency)
+}
+
+// Money returns the amount and currency of the transaction
+func (r Record) Money() Money {
+	return NewMoney(int64(r.Amount), r.Currency)
+}
+
+// RefundedMoney returns the refunded amount and currency of the transaction
+func (r Record) RefundedMoney() Money {
+	return NewMoney(int64(r.RefundedAmount), r.Currency)
+}
+
+// OriginalMoney returns the original amount and currency of the transaction
+func (r Record) OriginalMoney() Money {
+	return NewMoney(int64(r.OriginalAmount), r.Currency)
+}
+
+// OffsetMoney returns the amount offset by the redeemed points in the currency
+func (r RecordRedeemInfo) OffsetMoney(currency Currency) (Money, error) {
+	return parseMinorUnits(r.OffsetAmount, currency)
+}
+
+// DueMoney returns the amount due after the redemption in the currency
+func (r RecordRedeemInfo) DueMoney(currency Currency) (Money, error) {
+	return parseMinorUnits(r.DueAmount, currency)
+}
+
+// parseMinorUnits parses the amount string in the minor unit of the currency, treating empty string as zero
+func parseMinorUnits(amount string, currency Currency) (Money, error) {
+	if amount == "" {
+		return NewMoney(0, currency), nil
+	}
+	v, err := strconv.ParseInt(amount, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("tappay: invalid amount %q, err: %v", amount, err)
+	}
+	return NewMoney(v, currency), nil
+}
@@ -0,0 +1,89 @@
+package tappay
+
+import (
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	for _, tc := range []struct {
+		amount     string
+		currency   Currency
+		want       Money
+		wantString string
+		wantError  bool
+	}{
+		{amount: "100", currency: "TWD", want: Money{Amount: 100, Currency: "TWD"}, wantString: "TWD 100"},
+		{amount: "100", currency: "", want: Money{Amount: 100, Currency: "TWD"}, wantString: "TWD 100"},
+		{amount: "10.50", currency: "usd", want: Money{Amount: 1050, Currency: "USD"}, wantString: "USD 10.50"},
+		{amount: "0.05", currency: "USD", want: Money{Amount: 5, Currency: "USD"}, wantString: "USD 0.05"},
+		{amount: "-3", currency: "USD", want: Money{Amount: -300, Currency: "USD"}, wantString: "USD -3.00"},
+		{amount: "1500", currency: "JPY", want: Money{Amount: 1500, Currency: "JPY"}, wantString: "JPY 1500"},
+		{amount: "10.5", currency: "TWD", wantError: true},
+		{amount: "10.505", currency: "USD", wantError: true},
+		{amount: "10.", currency: "USD", wantError: true},
+		{amount: "abc", currency: "USD", wantError: true},
+		{amount: "", currency: "USD", wantError: true},
+	} {
+		t.Run(tc.amount+" "+string(tc.currency), func(t *testing.T) {
+			got, err := ParseMoney(tc.amount, tc.currency)
+			if tc.wantError {
+				if err == nil {
+					t.Errorf("expected error, got: %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected parse error: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("expected: %+v, got: %+v", tc.want, got)
+			}
+			if got.String() != tc.wantString {
+				t.Errorf("expected string: %s, got: %s", tc.wantString, got.String())
+			}
+		})
+	}
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	sum, err := NewMoney(1050, "USD").Add(NewMoney(25, "USD"))
+	if err != nil || sum != NewMoney(1075, "USD") {
+		t.Errorf("expected USD 10.75, got: %v, err: %v", sum, err)
+	}
+	diff, err := NewMoney(100, "").Sub(NewMoney(30, "TWD"))
+	if err != nil || diff != NewMoney(70, "TWD") {
+		t.Errorf("expected TWD 70, got: %v, err: %v", diff, err)
+	}
+	if c, err := NewMoney(100, "TWD").Cmp(NewMoney(30, "TWD")); err != nil || c != 1 {
+		t.Errorf("expected TWD 100 greater than TWD 30, got: %d, err: %v", c, err)
+	}
+	if _, err := NewMoney(100, "TWD").Add(NewMoney(100, "USD")); err != ErrCurrencyMismatch {
+		t.Errorf("expected ErrCurrencyMismatch, got: %v", err)
+	}
+}
+
+func TestMoneyConversions(t *testing.T) {
+	var params PaymentPrimeParams
+	params.SetMoney(NewMoney(1050, "USD"))
+	if params.Amount != 1050 || params.Currency != "USD" {
+		t.Errorf("expected amount 1050 USD, got: %d %s", params.Amount, params.Currency)
+	}
+
+	var refund RefundParams
+	refund.SetMoney(NewMoney(40, "TWD"))
+	if refund.Amount != "40" {
+		t.Errorf("expected refund amount 40, got: %s", refund.Amount)
+	}
+
+	record := Record{Amount: 100, RefundedAmount: 40, RedeemInfo: RecordRedeemInfo{OffsetAmount: "10", DueAmount: "90"}}
+	remaining, _ := record.Money().Sub(record.RefundedMoney())
+	if remaining != NewMoney(60, "TWD") {
+		t.Errorf("expected remaining TWD 60, got: %v", remaining)
+	}
+	if due, err := record.RedeemInfo.DueMoney(record.Currency); err != nil || due != NewMoney(90, "TWD") {
+		t.Errorf("expected due TWD 90, got: %v, err: %v", due, err)
+	}
+	if _, err := (RecordRedeemInfo{OffsetAmount: "x"}).OffsetMoney("TWD"); err == nil {
+		t.Errorf("expected error of invalid offset amount")
+	}
+}
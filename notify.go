@@ -0,0 +1,285 @@
+package tappay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// maxNotificationSize is the maximum number of bytes read from the body of the backend notification
+const maxNotificationSize = 1 << 20
+
+// Notification defines the payload of the backend notification sent by TapPay server to
+// PaymentParamsResultUrl.BackendNotifyUrl after a 3D secure or e-wallet payment is completed
+// More details in: https://docs.tappaysdk.com/tutorial/zh/back.html#backend-notify-api
+type Notification struct {
+	RecTradeID            string                      `json:"rec_trade_id"`
+	AuthCode              string                      `json:"auth_code"`
+	BankTransactionID     string                      `json:"bank_transaction_id"`
+	OrderNumber           string                      `json:"order_number"`
+	Amount                int                         `json:"amount"`
+	Currency              Currency                    `json:"currency"`
+	Status                int                         `json:"status"`
+	Msg                   string                      `json:"msg"`
+	TransactionTimeMillis int64                       `json:"transaction_time_millis"`
+	PayInfo               RecordPayInfo               `json:"pay_info"`
+	Acquirer              string                      `json:"acquirer"`
+	CardIdentifier        string                      `json:"card_identifier"`
+	BankResultCode        string                      `json:"bank_result_code"`
+	BankResultMsg         string                      `json:"bank_result_msg"`
+	CardInfo              RecordCardInfo              `json:"card_info"`
+	MerchantReferenceInfo RecordMerchantReferenceInfo `json:"merchant_reference_info"`
+	EventCode             string                      `json:"event_code"`
+}
+
+// StatusCode returns the status of the notification as StatusCode
+func (n Notification) StatusCode() StatusCode {
+	return StatusCode(n.Status)
+}
+
+// TransactionTime returns the transaction time as time.Time in TaipeiLocation
+func (n Notification) TransactionTime() time.Time {
+	return millisToTime(n.TransactionTimeMillis)
+}
+
+// NotifyFunc is the callback invoked by NotifyHandler with the received notification.
+// A non-nil error makes the handler respond a server error so that TapPay server retries the notification.
+type NotifyFunc func(ctx context.Context, n *Notification) error
+
+// ErrInvalidNotification is reported to the error handler of NotifyHandler when
+// the request is not a valid backend notification
+var ErrInvalidNotification = errors.New("tappay: invalid backend notification")
+
+// NotifyHandler is the http.Handler receiving the backend notifications of TapPay server
+//
+//	http.Handle("/tappay/notify", tappay.NewNotifyHandler(func(ctx context.Context, n *tappay.Notification) error {
+//		return orders.MarkPaid(ctx, n.OrderNumber, n.RecTradeID)
+//	}))
+type NotifyHandler struct {
+	callback NotifyFunc
+
+	// querier is used to verify the notification against the record if it is not nil
+	querier RecordsQuerier
+
+	// store is used to deduplicate the notifications if it is not nil
+	store NotificationStore
+
+	// onError is called with the request and the error which makes the handler
+	// respond other than acknowledgement
+	onError func(r *http.Request, err error)
+}
+
+type notifyOption func(*NotifyHandler)
+
+// NewNotifyHandler creates a NotifyHandler invoking callback with the received notifications
+func NewNotifyHandler(callback NotifyFunc, options ...notifyOption) *NotifyHandler {
+	h := &NotifyHandler{
+		callback: callback,
+		onError:  func(*http.Request, error) {},
+	}
+	for _, option := range options {
+		option(h)
+	}
+	return h
+}
+
+// WithNotifyErrorHandler returns a notifyOption to report the errors of handling notification,
+// e.g. for logging
+func WithNotifyErrorHandler(fn func(r *http.Request, err error)) notifyOption {
+	return func(h *NotifyHandler) {
+		h.onError = fn
+	}
+}
+
+// WithNotifyVerification returns a notifyOption to verify the notification before invoking the callback,
+// by looking up the record of the rec_trade_id with the querier, e.g. the client, and confirming that the
+// amount, currency, order number and record status match. Since the backend notifications are not signed,
+// this rejects the forged notifications with a NotificationVerificationError.
+func WithNotifyVerification(querier RecordsQuerier) notifyOption {
+	return func(h *NotifyHandler) {
+		h.querier = querier
+	}
+}
+
+// ServeHTTP implements the http.Handler interface. It acknowledges the notification with status 200
+// after the callback succeeds. It responds 400 for invalid notification or the notification failing
+// the verification, and 500 if the verification cannot be done yet or the callback fails, so that
+// TapPay server retries later. The duplicate notification is acknowledged without invoking the
+// callback if the NotificationStore is configured, and the notification being processed by another
+// request responds 500.
+func (h *NotifyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	n, err := parseNotification(r.Body)
+	if err != nil {
+		h.onError(r, err)
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	if h.querier != nil {
+		if err := verifyNotification(r.Context(), h.querier, n); err != nil {
+			h.onError(r, err)
+			var verr *NotificationVerificationError
+			if errors.As(err, &verr) {
+				http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			} else {
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			}
+			return
+		}
+	}
+
+	var key string
+	if h.store != nil {
+		key = notificationKey(n)
+		begun, err := h.store.Begin(r.Context(), key)
+		if err != nil {
+			h.onError(r, err)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		if !begun {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+	}
+
+	if err := h.callback(r.Context(), n); err != nil {
+		h.onError(r, err)
+		if h.store != nil {
+			if err := h.store.Abort(r.Context(), key); err != nil {
+				h.onError(r, err)
+			}
+		}
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	if h.store != nil {
+		// acknowledges the processed notification even if it cannot be recorded,
+		// since the redelivery would invoke the callback again
+		if err := h.store.Commit(r.Context(), key); err != nil {
+			h.onError(r, err)
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// parseNotification parses the notification from the request body
+func parseNotification(body io.Reader) (*Notification, error) {
+	b, err := ioutil.ReadAll(io.LimitReader(body, maxNotificationSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: cannot read body: %v", ErrInvalidNotification, err)
+	}
+	if len(b) > maxNotificationSize {
+		return nil, fmt.Errorf("%w: body exceeds %d bytes", ErrInvalidNotification, maxNotificationSize)
+	}
+
+	var n Notification
+	if err := json.Unmarshal(b, &n); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidNotification, err)
+	}
+	if n.RecTradeID == "" {
+		return nil, fmt.Errorf("%w: rec_trade_id is missing", ErrInvalidNotification)
+	}
+	return &n, nil
+}
+
+// ErrRecordPending is reported to the error handler of NotifyHandler when the record of the
+// successful notification is still pending. The handler responds a server error so that
+// TapPay server retries the notification after the record is updated.
+var ErrRecordPending = errors.New("tappay: record of the notification is still pending")
+
+// RecordsQuerier is the interface implemented by the client querying the records
+type RecordsQuerier interface {
+	Records(ctx context.Context, params RecordParams) (*RecordResponse, error)
+}
+
+// NotificationVerificationError is the error of the notification which does not match its record
+type NotificationVerificationError struct {
+	RecTradeID string
+
+	// Field is the field of the notification which does not match, e.g. `amount`.
+	// It is `rec_trade_id` if the record is not found.
+	Field    string
+	Notified string
+	Recorded string
+}
+
+// Error implements the error interface
+func (e *NotificationVerificationError) Error() string {
+	if e.Field == "rec_trade_id" {
+		return fmt.Sprintf("tappay: notification of %s does not match any record", e.RecTradeID)
+	}
+	return fmt.Sprintf("tappay: notification of %s does not match its record, %s: notified %s, recorded %s",
+		e.RecTradeID, e.Field, e.Notified, e.Recorded)
+}
+
+// verifyNotification confirms the notification against its record queried by querier
+func verifyNotification(ctx context.Context, querier RecordsQuerier, n *Notification) error {
+	resp, err := querier.Records(ctx, RecordParams{Filters: &RecordFilters{RecTradeID: n.RecTradeID}})
+	if err != nil {
+		return fmt.Errorf("tappay: cannot query the record of notification, err: %w", err)
+	}
+	switch resp.StatusCode() {
+	case StatusSuccess, StatusNoRecord:
+	default:
+		return &APIError{Status: resp.Status, Msg: resp.Msg, RecTradeID: n.RecTradeID, Service: string(serviceRecord)}
+	}
+
+	var record *Record
+	for i := range resp.TradeRecords {
+		if resp.TradeRecords[i].RecTradeID == n.RecTradeID {
+			record = &resp.TradeRecords[i]
+			break
+		}
+	}
+	mismatch := func(field string, notified, recorded interface{}) error {
+		return &NotificationVerificationError{
+			RecTradeID: n.RecTradeID,
+			Field:      field,
+			Notified:   fmt.Sprint(notified),
+			Recorded:   fmt.Sprint(recorded),
+		}
+	}
+	if record == nil {
+		return mismatch("rec_trade_id", n.RecTradeID, "")
+	}
+
+	if n.Amount != record.Amount {
+		return mismatch("amount", n.Amount, record.Amount)
+	}
+	// the omitted currency denotes the default currency, i.e. TWD
+	if normalizeCurrency(n.Currency) != normalizeCurrency(record.Currency) {
+		return mismatch("currency", n.Currency, record.Currency)
+	}
+	if n.OrderNumber != record.OrderNumber {
+		return mismatch("order_number", n.OrderNumber, record.OrderNumber)
+	}
+	if n.StatusCode() == StatusSuccess && record.RecordStatus == RecordStatusPending {
+		// the record is usually updated shortly after the notification
+		return fmt.Errorf("%w, rec_trade_id: %s", ErrRecordPending, n.RecTradeID)
+	}
+	if paid := n.StatusCode() == StatusSuccess; paid != isPaid(record.RecordStatus) {
+		return mismatch("status", n.Status, record.RecordStatus)
+	}
+	return nil
+}
+
+// isPaid reports whether the record status denotes the transaction has been authorized successfully
+func isPaid(status RecordStatus) bool {
+	switch status {
+	case RecordStatusAuth, RecordStatusOK, RecordStatusPartialRefunded, RecordStatusRefunded:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,193 @@
+package tappay
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrNotificationInFlight is returned by NotificationStore.Begin when the notification is being
+// processed by another request. NotifyHandler responds a server error so that TapPay server retries later.
+var ErrNotificationInFlight = errors.New("tappay: notification is being processed")
+
+// NotificationStore defines the store of processed notifications, used by NotifyHandler to
+// deduplicate the notifications delivered multiple times by TapPay server. The key is recorded
+// only after the callback succeeds, so that the notification interrupted by a crash is processed
+// again on redelivery.
+type NotificationStore interface {
+	// Begin marks the key of the notification in flight before invoking the callback. It returns
+	// false if the key has been committed, and ErrNotificationInFlight if the key is in flight.
+	Begin(ctx context.Context, key string) (bool, error)
+
+	// Commit records the key of the notification as processed after the callback succeeds.
+	// The key is no longer in flight even if it cannot be recorded.
+	Commit(ctx context.Context, key string) error
+
+	// Abort releases the key of the notification after the callback fails,
+	// so that the redelivery of the notification is processed again
+	Abort(ctx context.Context, key string) error
+}
+
+// WithNotificationStore returns a notifyOption to deduplicate the notifications by rec_trade_id and status
+// with the store. The duplicate notification is acknowledged without invoking the callback.
+func WithNotificationStore(store NotificationStore) notifyOption {
+	return func(h *NotifyHandler) {
+		h.store = store
+	}
+}
+
+// notificationKey returns the deduplication key of the notification
+func notificationKey(n *Notification) string {
+	return n.RecTradeID + ":" + strconv.Itoa(n.Status)
+}
+
+// notificationKeys defines the processed and in-flight keys shared by the NotificationStore implementations.
+// The caller must hold the lock of the store.
+type notificationKeys struct {
+	processed map[string]bool
+	inFlight  map[string]bool
+}
+
+func newNotificationKeys() notificationKeys {
+	return notificationKeys{processed: make(map[string]bool), inFlight: make(map[string]bool)}
+}
+
+// begin marks the key in flight, see NotificationStore.Begin
+func (k notificationKeys) begin(key string) (bool, error) {
+	if k.processed[key] {
+		return false, nil
+	}
+	if k.inFlight[key] {
+		return false, fmt.Errorf("%w, key: %s", ErrNotificationInFlight, key)
+	}
+	k.inFlight[key] = true
+	return true, nil
+}
+
+// MemoryNotificationStore defines the NotificationStore keeping the keys in memory.
+// The keys are lost when the process exits, thus it is suitable for single instance or testing.
+type MemoryNotificationStore struct {
+	mu   sync.Mutex
+	keys notificationKeys
+}
+
+// NewMemoryNotificationStore creates an empty MemoryNotificationStore
+func NewMemoryNotificationStore() *MemoryNotificationStore {
+	return &MemoryNotificationStore{keys: newNotificationKeys()}
+}
+
+// Begin implements the NotificationStore interface
+func (s *MemoryNotificationStore) Begin(ctx context.Context, key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.keys.begin(key)
+}
+
+// Commit implements the NotificationStore interface
+func (s *MemoryNotificationStore) Commit(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.keys.inFlight, key)
+	s.keys.processed[key] = true
+	return nil
+}
+
+// Abort implements the NotificationStore interface
+func (s *MemoryNotificationStore) Abort(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.keys.inFlight, key)
+	return nil
+}
+
+// FileNotificationStore defines the NotificationStore persisting the committed keys in an append-only
+// file, one `+key` line per Commit, which is replayed when the store is opened. The in-flight keys are
+// kept in memory only, so that they are released if the process crashes during the callback.
+type FileNotificationStore struct {
+	mu   sync.Mutex
+	file *os.File
+	keys notificationKeys
+}
+
+// OpenFileNotificationStore opens the FileNotificationStore of the file at path, creating it if not exists
+func OpenFileNotificationStore(path string) (*FileNotificationStore, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open notification store, err: %v", err)
+	}
+
+	keys := newNotificationKeys()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		if line[0] != '+' {
+			f.Close()
+			return nil, fmt.Errorf("cannot read notification store, invalid line: %q", line)
+		}
+		keys.processed[line[1:]] = true
+	}
+	if err := scanner.Err(); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("cannot read notification store, err: %v", err)
+	}
+	return &FileNotificationStore{file: f, keys: keys}, nil
+}
+
+// Begin implements the NotificationStore interface
+func (s *FileNotificationStore) Begin(ctx context.Context, key string) (bool, error) {
+	if strings.ContainsAny(key, "\r\n") {
+		return false, fmt.Errorf("cannot begin notification, invalid key: %q", key)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.keys.begin(key)
+}
+
+// Commit implements the NotificationStore interface
+func (s *FileNotificationStore) Commit(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.keys.inFlight, key)
+	if s.keys.processed[key] {
+		return nil
+	}
+	if _, err := s.file.WriteString("+" + key + "\n"); err != nil {
+		return fmt.Errorf("cannot write notification store, err: %v", err)
+	}
+	if err := s.file.Sync(); err != nil {
+		return fmt.Errorf("cannot sync notification store, err: %v", err)
+	}
+	s.keys.processed[key] = true
+	return nil
+}
+
+// Abort implements the NotificationStore interface
+func (s *FileNotificationStore) Abort(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.keys.inFlight, key)
+	return nil
+}
+
+// Close closes the underlying file
+func (s *FileNotificationStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.file.Close()
+}
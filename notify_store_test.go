@@ -0,0 +1,160 @@
+package tappay
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNotificationStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tappay")
+	if err != nil {
+		t.Fatalf("cannot create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "notifications")
+
+	fileStore, err := OpenFileNotificationStore(path)
+	if err != nil {
+		t.Fatalf("cannot open file store: %v", err)
+	}
+	defer fileStore.Close()
+
+	for _, tc := range []struct {
+		name  string
+		store NotificationStore
+	}{
+		{name: "MemoryNotificationStore", store: NewMemoryNotificationStore()},
+		{name: "FileNotificationStore", store: fileStore},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			for _, step := range []struct {
+				op           string
+				key          string
+				wantBegun    bool
+				wantInFlight bool
+			}{
+				{op: "begin", key: "D1:0", wantBegun: true},
+				{op: "begin", key: "D1:0", wantInFlight: true},
+				{op: "commit", key: "D1:0"},
+				{op: "begin", key: "D1:0", wantBegun: false},
+				{op: "begin", key: "D1:10003", wantBegun: true},
+				{op: "begin", key: "D2:0", wantBegun: true},
+				{op: "abort", key: "D2:0"},
+				{op: "begin", key: "D2:0", wantBegun: true},
+			} {
+				switch step.op {
+				case "commit":
+					if err := tc.store.Commit(ctx, step.key); err != nil {
+						t.Fatalf("unexpected commit error: %v", err)
+					}
+				case "abort":
+					if err := tc.store.Abort(ctx, step.key); err != nil {
+						t.Fatalf("unexpected abort error: %v", err)
+					}
+				default:
+					begun, err := tc.store.Begin(ctx, step.key)
+					if errors.Is(err, ErrNotificationInFlight) != step.wantInFlight {
+						t.Fatalf("expected in flight of %s: %t, got: %v", step.key, step.wantInFlight, err)
+					}
+					if !step.wantInFlight && err != nil {
+						t.Fatalf("unexpected begin error: %v", err)
+					}
+					if begun != step.wantBegun {
+						t.Errorf("expected begun of %s: %t, got: %t", step.key, step.wantBegun, begun)
+					}
+				}
+			}
+		})
+	}
+
+	if _, err := fileStore.Begin(context.Background(), "D3\n:0"); err == nil {
+		t.Errorf("expected error of key with newline")
+	}
+	// D1:10003 and D2:0 are in flight when the process crashes
+	if err := fileStore.Close(); err != nil {
+		t.Fatalf("unexpected close error: %v", err)
+	}
+
+	reopened, err := OpenFileNotificationStore(path)
+	if err != nil {
+		t.Fatalf("cannot reopen file store: %v", err)
+	}
+	defer reopened.Close()
+	for key, want := range map[string]bool{"D1:0": false, "D2:0": true, "D1:10003": true} {
+		begun, err := reopened.Begin(context.Background(), key)
+		if err != nil {
+			t.Fatalf("unexpected begin error: %v", err)
+		}
+		if begun != want {
+			t.Errorf("expected begun of %s after reopen: %t, got: %t", key, want, begun)
+		}
+	}
+}
+
+func TestOpenFileNotificationStoreInvalidFile(t *testing.T) {
+	f, err := ioutil.TempFile("", "tappay")
+	if err != nil {
+		t.Fatalf("cannot create temp file: %v", err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString("+D1:0\nD2:0\n")
+	f.Close()
+
+	if _, err := OpenFileNotificationStore(f.Name()); err == nil {
+		t.Errorf("expected error of invalid line")
+	}
+}
+
+func TestNotifyHandlerDeduplication(t *testing.T) {
+	var calls int
+	var callbackErr error
+	var h *NotifyHandler
+	deliver := func(body string) int {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/notify", strings.NewReader(body)))
+		return w.Code
+	}
+
+	var inFlightCode int
+	h = NewNotifyHandler(func(ctx context.Context, n *Notification) error {
+		calls++
+		if calls == 1 {
+			// the redelivery arrives while the first delivery is being processed
+			inFlightCode = deliver(testNotificationBody)
+		}
+		return callbackErr
+	}, WithNotificationStore(NewMemoryNotificationStore()))
+
+	callbackErr = errors.New("database is down")
+	if code := deliver(testNotificationBody); code != http.StatusInternalServerError {
+		t.Errorf("expected status code of failed callback: %d, got: %d", http.StatusInternalServerError, code)
+	}
+	if inFlightCode != http.StatusInternalServerError {
+		t.Errorf("expected status code of in-flight notification: %d, got: %d", http.StatusInternalServerError, inFlightCode)
+	}
+
+	callbackErr = nil
+	for i := 0; i < 2; i++ {
+		if code := deliver(testNotificationBody); code != http.StatusOK {
+			t.Errorf("expected status code of delivery %d: %d, got: %d", i, http.StatusOK, code)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("expected callback called 2 times for the retry after failure, got: %d", calls)
+	}
+
+	if code := deliver(testFailedNotificationBody); code != http.StatusOK {
+		t.Errorf("expected status code: %d, got: %d", http.StatusOK, code)
+	}
+	if calls != 3 {
+		t.Errorf("expected callback called for different notification, got: %d", calls)
+	}
+}
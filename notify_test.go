@@ -0,0 +1,275 @@
+package tappay
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// testNotificationBody is a canned backend notification of a successful 3D secure payment
+const testNotificationBody = `{
+	"rec_trade_id": "D20200101abc",
+	"auth_code": "123456",
+	"bank_transaction_id": "TP20200101abc",
+	"order_number": "order-1",
+	"amount": 100,
+	"currency": "TWD",
+	"status": 0,
+	"msg": "Success",
+	"transaction_time_millis": 1577836800000,
+	"pay_info": {"method": "CREDIT_CARD", "masked_credit_card_number": "424242******4242"},
+	"acquirer": "TW_CTBC",
+	"card_identifier": "identifier",
+	"bank_result_code": "00",
+	"bank_result_msg": "",
+	"card_info": {"bin_code": "424242", "last_four": "4242", "issuer": "JPMORGAN CHASE BANK NA", "funding": 0, "type": 1, "country_code": "US"},
+	"merchant_reference_info": {"affiliate_codes": []},
+	"event_code": ""
+}`
+
+// testFailedNotificationBody is a canned backend notification of a failed 3D secure payment
+const testFailedNotificationBody = `{
+	"rec_trade_id": "D20200101def",
+	"order_number": "order-2",
+	"amount": 100,
+	"status": 10003,
+	"msg": "Card Error",
+	"bank_result_code": "05",
+	"bank_result_msg": "Do not honour"
+}`
+
+func TestNotifyHandler(t *testing.T) {
+	for _, tc := range []struct {
+		name            string
+		method          string
+		body            string
+		callbackErr     error
+		wantCode        int
+		wantCalled      bool
+		wantNotifyErr   error
+		wantNotifyCheck func(t *testing.T, n *Notification)
+	}{
+		{
+			name:       "Given successful notification invokes callback and acknowledges",
+			method:     http.MethodPost,
+			body:       testNotificationBody,
+			wantCode:   http.StatusOK,
+			wantCalled: true,
+			wantNotifyCheck: func(t *testing.T, n *Notification) {
+				if n.RecTradeID != "D20200101abc" || n.OrderNumber != "order-1" || n.Amount != 100 || n.Currency != CurrencyTWD {
+					t.Errorf("unexpected notification: %+v", n)
+				}
+				if n.CardInfo.LastFour != "4242" || n.CardInfo.Type != CardTypeVisa || n.BankResultCode != "00" {
+					t.Errorf("unexpected card info or bank result: %+v", n)
+				}
+			},
+		},
+		{
+			name:       "Given failed notification invokes callback with the status",
+			method:     http.MethodPost,
+			body:       testFailedNotificationBody,
+			wantCode:   http.StatusOK,
+			wantCalled: true,
+			wantNotifyCheck: func(t *testing.T, n *Notification) {
+				if n.StatusCode() != StatusCardError || n.BankResultMsg != "Do not honour" {
+					t.Errorf("unexpected notification: %+v", n)
+				}
+			},
+		},
+		{
+			name:          "Given callback error responds server error for retry",
+			method:        http.MethodPost,
+			body:          testNotificationBody,
+			callbackErr:   errors.New("database is down"),
+			wantCode:      http.StatusInternalServerError,
+			wantCalled:    true,
+			wantNotifyErr: errors.New("database is down"),
+		},
+		{
+			name:          "Given malformed body responds bad request",
+			method:        http.MethodPost,
+			body:          `{"rec_trade_id":`,
+			wantCode:      http.StatusBadRequest,
+			wantNotifyErr: ErrInvalidNotification,
+		},
+		{
+			name:          "Given body without rec_trade_id responds bad request",
+			method:        http.MethodPost,
+			body:          `{"status":0}`,
+			wantCode:      http.StatusBadRequest,
+			wantNotifyErr: ErrInvalidNotification,
+		},
+		{
+			name:     "Given GET request responds method not allowed",
+			method:   http.MethodGet,
+			wantCode: http.StatusMethodNotAllowed,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var called bool
+			var notifyErr error
+			h := NewNotifyHandler(func(ctx context.Context, n *Notification) error {
+				called = true
+				if tc.wantNotifyCheck != nil {
+					tc.wantNotifyCheck(t, n)
+				}
+				return tc.callbackErr
+			}, WithNotifyErrorHandler(func(r *http.Request, err error) {
+				notifyErr = err
+			}))
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(tc.method, "/notify", strings.NewReader(tc.body)))
+			if w.Code != tc.wantCode {
+				t.Errorf("expected status code: %d, got: %d", tc.wantCode, w.Code)
+			}
+			if called != tc.wantCalled {
+				t.Errorf("expected callback called: %t, got: %t", tc.wantCalled, called)
+			}
+			switch {
+			case tc.wantNotifyErr == nil && notifyErr != nil:
+				t.Errorf("unexpected error reported: %v", notifyErr)
+			case tc.wantNotifyErr == ErrInvalidNotification && !errors.Is(notifyErr, ErrInvalidNotification):
+				t.Errorf("expected ErrInvalidNotification reported, got: %v", notifyErr)
+			case tc.wantNotifyErr != nil && (notifyErr == nil || notifyErr.Error() != tc.wantNotifyErr.Error()) && !errors.Is(notifyErr, tc.wantNotifyErr):
+				t.Errorf("expected error reported: %v, got: %v", tc.wantNotifyErr, notifyErr)
+			}
+		})
+	}
+}
+
+// recordsQuerierFunc adapts the function into RecordsQuerier
+type recordsQuerierFunc func(ctx context.Context, params RecordParams) (*RecordResponse, error)
+
+func (f recordsQuerierFunc) Records(ctx context.Context, params RecordParams) (*RecordResponse, error) {
+	return f(ctx, params)
+}
+
+func TestNotifyHandlerVerification(t *testing.T) {
+	paid := Record{RecTradeID: "D20200101abc", OrderNumber: "order-1", Amount: 100, Currency: CurrencyTWD, RecordStatus: RecordStatusOK}
+	for _, tc := range []struct {
+		name        string
+		body        string
+		records     []Record
+		queryErr    error
+		wantCode    int
+		wantCalled  bool
+		wantField   string
+		wantErrorIs error
+	}{
+		{
+			name:       "Given matching record invokes callback",
+			records:    []Record{paid},
+			wantCode:   http.StatusOK,
+			wantCalled: true,
+		},
+		{
+			name:      "Given no record rejects the notification",
+			wantCode:  http.StatusBadRequest,
+			wantField: "rec_trade_id",
+		},
+		{
+			name:      "Given different amount rejects the notification",
+			records:   []Record{{RecTradeID: "D20200101abc", OrderNumber: "order-1", Amount: 1, Currency: CurrencyTWD, RecordStatus: RecordStatusOK}},
+			wantCode:  http.StatusBadRequest,
+			wantField: "amount",
+		},
+		{
+			name:      "Given different currency rejects the notification",
+			records:   []Record{{RecTradeID: "D20200101abc", OrderNumber: "order-1", Amount: 100, Currency: CurrencyUSD, RecordStatus: RecordStatusOK}},
+			wantCode:  http.StatusBadRequest,
+			wantField: "currency",
+		},
+		{
+			name:      "Given omitted currency rejects the notification of USD record",
+			body:      strings.Replace(testNotificationBody, `"currency": "TWD",`, "", 1),
+			records:   []Record{{RecTradeID: "D20200101abc", OrderNumber: "order-1", Amount: 100, Currency: CurrencyUSD, RecordStatus: RecordStatusOK}},
+			wantCode:  http.StatusBadRequest,
+			wantField: "currency",
+		},
+		{
+			name:       "Given omitted currency accepts the notification of TWD record",
+			body:       strings.Replace(testNotificationBody, `"currency": "TWD",`, "", 1),
+			records:    []Record{paid},
+			wantCode:   http.StatusOK,
+			wantCalled: true,
+		},
+		{
+			name:      "Given different order number rejects the notification",
+			records:   []Record{{RecTradeID: "D20200101abc", OrderNumber: "order-2", Amount: 100, Currency: CurrencyTWD, RecordStatus: RecordStatusOK}},
+			wantCode:  http.StatusBadRequest,
+			wantField: "order_number",
+		},
+		{
+			name:        "Given pending record responds server error for retry",
+			records:     []Record{{RecTradeID: "D20200101abc", OrderNumber: "order-1", Amount: 100, Currency: CurrencyTWD, RecordStatus: RecordStatusPending}},
+			wantCode:    http.StatusInternalServerError,
+			wantErrorIs: ErrRecordPending,
+		},
+		{
+			name:      "Given failed record rejects the paid notification",
+			records:   []Record{{RecTradeID: "D20200101abc", OrderNumber: "order-1", Amount: 100, Currency: CurrencyTWD, RecordStatus: RecordStatusError}},
+			wantCode:  http.StatusBadRequest,
+			wantField: "status",
+		},
+		{
+			name:     "Given query error responds server error for retry",
+			queryErr: errors.New("connection refused"),
+			wantCode: http.StatusInternalServerError,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			querier := recordsQuerierFunc(func(ctx context.Context, params RecordParams) (*RecordResponse, error) {
+				if params.Filters == nil || params.Filters.RecTradeID != "D20200101abc" {
+					t.Errorf("expected query by rec_trade_id, got: %+v", params.Filters)
+				}
+				if tc.queryErr != nil {
+					return nil, tc.queryErr
+				}
+				if len(tc.records) == 0 {
+					return &RecordResponse{Status: int(StatusNoRecord)}, nil
+				}
+				return &RecordResponse{Status: int(StatusSuccess), TradeRecords: tc.records}, nil
+			})
+
+			var called bool
+			var notifyErr error
+			h := NewNotifyHandler(func(ctx context.Context, n *Notification) error {
+				called = true
+				return nil
+			}, WithNotifyVerification(querier), WithNotifyErrorHandler(func(r *http.Request, err error) {
+				notifyErr = err
+			}))
+
+			w := httptest.NewRecorder()
+			body := tc.body
+			if body == "" {
+				body = testNotificationBody
+			}
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/notify", strings.NewReader(body)))
+			if w.Code != tc.wantCode {
+				t.Errorf("expected status code: %d, got: %d", tc.wantCode, w.Code)
+			}
+			if called != tc.wantCalled {
+				t.Errorf("expected callback called: %t, got: %t", tc.wantCalled, called)
+			}
+
+			if tc.wantErrorIs != nil && !errors.Is(notifyErr, tc.wantErrorIs) {
+				t.Errorf("expected error: %v, got: %v", tc.wantErrorIs, notifyErr)
+			}
+			var verr *NotificationVerificationError
+			if tc.wantField == "" {
+				if errors.As(notifyErr, &verr) {
+					t.Errorf("unexpected verification error: %v", verr)
+				}
+				return
+			}
+			if !errors.As(notifyErr, &verr) || verr.Field != tc.wantField {
+				t.Errorf("expected verification error of %s, got: %v", tc.wantField, notifyErr)
+			}
+		})
+	}
+}
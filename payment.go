@@ -5,11 +5,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 )
 
 // payByPrimePath defines the path of pay-by-prime service
 const payByPrimePath = "/tpc/payment/pay-by-prime"
 
+// payByTokenPath defines the path of pay-by-token service
+const payByTokenPath = "/tpc/payment/pay-by-token"
+
 // PaymentParamsCardholder defines the field `cardholder` in request to pay-by-prime api
 // See PaymentPrimeParams for more details
 type PaymentParamsCardholder struct {
@@ -44,7 +48,7 @@ type PaymentPrimeParams struct {
 	MerchantGroupID    string                         `json:"merchant_group_id,omitempty"`
 	Amount             int                            `json:"amount"`
 	MerchandiseDetails *RecordMerchandiseDetails      `json:"merchandise_details,omitempty"`
-	Currency           string                         `json:"currency,omitempty"`
+	Currency           Currency                       `json:"currency,omitempty"`
 	OrderNumber        string                         `json:"order_number,omitempty"`
 	BankTransactionID  string                         `json:"bank_transaction_id,omitempty"`
 	Details            string                         `json:"details"`
@@ -75,6 +79,45 @@ func (r PaymentPrimeParams) MarshalMap() (map[string]interface{}, error) {
 	return m, nil
 }
 
+// PaymentTokenParams defines the parameters for performing pay-by-token operation
+// with the PaymentCardSecret obtained from a previous payment with `remember` enabled
+// More details in: https://docs.tappaysdk.com/tutorial/zh/back.html#pay-by-token-api
+type PaymentTokenParams struct {
+	CardKey            string                    `json:"card_key"`
+	CardToken          string                    `json:"card_token"`
+	MerchantID         string                    `json:"merchant_id"`
+	MerchantGroupID    string                    `json:"merchant_group_id,omitempty"`
+	Amount             int                       `json:"amount"`
+	MerchandiseDetails *RecordMerchandiseDetails `json:"merchandise_details,omitempty"`
+	Currency           Currency                  `json:"currency"`
+	OrderNumber        string                    `json:"order_number,omitempty"`
+	BankTransactionID  string                    `json:"bank_transaction_id,omitempty"`
+	Details            string                    `json:"details"`
+	Instalment         int                       `json:"instalment,omitempty"`
+	DelayCaptureInDays int                       `json:"delay_capture_in_days,omitempty"`
+	ThreeDomainSecure  bool                      `json:"three_domain_secure,omitempty"`
+	ResultUrl          *PaymentParamsResultUrl   `json:"result_url,omitempty"`
+	CcvPrime           string                    `json:"ccv_prime,omitempty"`
+	Redeem             bool                      `json:"redeem,omitempty"`
+	AdditionalData     json.RawMessage           `json:"additional_data,omitempty"`
+	EventCode          string                    `json:"event_code,omitempty"`
+	ProductImageUrl    string                    `json:"product_image_url,omitempty"`
+}
+
+// MarshalMap implements the Marshaler interface
+func (r PaymentTokenParams) MarshalMap() (map[string]interface{}, error) {
+	p, err := json.Marshal(&r)
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal PaymentTokenParams: %v, err: %v", r, err)
+	}
+	var m map[string]interface{}
+	if err = json.Unmarshal(p, &m); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal PaymentTokenParams into map, err: %v", err)
+	}
+
+	return m, nil
+}
+
 // PaymentCardSecret defines the field `card_secret` in PaymentPrimeResponse
 // See PaymentPrimeResponse for more details
 type PaymentCardSecret struct {
@@ -112,17 +155,16 @@ type PaymentRedeemInfo struct {
 	ExtraInfo PaymentRedeemExtraInfo `json:"extra_info"`
 }
 
-// PaymentPrimeResponse defines the API response returns by TapPay server after pay-by-prime request
-// More details in: https://docs.tappaysdk.com/tutorial/zh/back.html#response
-type PaymentPrimeResponse struct {
+// PaymentResponse defines the fields shared by the API responses of the payment services,
+// i.e. pay-by-prime and pay-by-token
+type PaymentResponse struct {
 	Status                int                         `json:"status"`
 	Msg                   string                      `json:"msg"`
 	RecTradeID            string                      `json:"rec_trade_id"`
 	BankTransactionID     string                      `json:"bank_transaction_id"`
 	AuthCode              string                      `json:"auth_code"`
-	CardSecret            PaymentCardSecret           `json:"card_secret"`
 	Amount                int                         `json:"amount"`
-	Currency              string                      `json:"currency"`
+	Currency              Currency                    `json:"currency"`
 	CardInfo              PaymentCardInfo             `json:"card_info"`
 	OrderNumber           string                      `json:"order_number"`
 	Acquirer              string                      `json:"acquirer"`
@@ -136,19 +178,42 @@ type PaymentPrimeResponse struct {
 	CardIdentifier        string                      `json:"card_identifier"`
 	MerchantReferenceInfo RecordMerchantReferenceInfo `json:"merchant_reference_info"`
 	EventCode             string                      `json:"event_code"`
+
+	// Recovered denotes the response is recovered from the record of the trade by WithOutcomeResolver,
+	// where the fields absent from the record, e.g. Acquirer, PaymentUrl and CardSecret, are empty
+	Recovered bool `json:"-"`
+}
+
+// PaymentPrimeResponse defines the API response returns by TapPay server after pay-by-prime request
+// More details in: https://docs.tappaysdk.com/tutorial/zh/back.html#response
+type PaymentPrimeResponse struct {
+	PaymentResponse
+	CardSecret PaymentCardSecret `json:"card_secret"`
 }
 
 // PayByPrime issues a pay-by-prime request according to input PaymentPrimeParams
-// and parses the response from TapPay server as PaymentPrimeResponse
+// and parses the response from TapPay server as PaymentPrimeResponse.
+// See WithOutcomeResolver for resolving the outcome when the request fails in transport.
 func (c *client) PayByPrime(ctx context.Context, params PaymentPrimeParams) (*PaymentPrimeResponse, error) {
 	req, err := c.newRequest(ctx, http.MethodPost, servicePayByPrime, params)
 	if err != nil {
 		return nil, err
 	}
 
+	payment := PaymentOutcomeParams{
+		OrderNumber:       params.OrderNumber,
+		BankTransactionID: params.BankTransactionID,
+		Amount:            params.Amount,
+		Currency:          params.Currency,
+		RequestTime:       time.Now(),
+	}
 	rawResp, err := c.do(req)
 	if err != nil {
-		return nil, err
+		recovered, err := c.resolvePayment(ctx, servicePayByPrime, payment, err)
+		if err != nil {
+			return nil, err
+		}
+		return &PaymentPrimeResponse{PaymentResponse: *recovered}, nil
 	}
 
 	var resp PaymentPrimeResponse
@@ -156,5 +221,44 @@ func (c *client) PayByPrime(ctx context.Context, params PaymentPrimeParams) (*Pa
 		return nil, err
 	}
 
-	return &resp, nil
+	return &resp, c.checkStatus(servicePayByPrime, "", rawResp)
+}
+
+// PaymentTokenResponse defines the API response returns by TapPay server after pay-by-token request
+// More details in: https://docs.tappaysdk.com/tutorial/zh/back.html#response2
+type PaymentTokenResponse struct {
+	PaymentResponse
+}
+
+// PayByToken issues a pay-by-token request according to input PaymentTokenParams
+// and parses the response from TapPay server as PaymentTokenResponse.
+// See WithOutcomeResolver for resolving the outcome when the request fails in transport.
+func (c *client) PayByToken(ctx context.Context, params PaymentTokenParams) (*PaymentTokenResponse, error) {
+	req, err := c.newRequest(ctx, http.MethodPost, servicePayByToken, params)
+	if err != nil {
+		return nil, err
+	}
+
+	payment := PaymentOutcomeParams{
+		OrderNumber:       params.OrderNumber,
+		BankTransactionID: params.BankTransactionID,
+		Amount:            params.Amount,
+		Currency:          params.Currency,
+		RequestTime:       time.Now(),
+	}
+	rawResp, err := c.do(req)
+	if err != nil {
+		recovered, err := c.resolvePayment(ctx, servicePayByToken, payment, err)
+		if err != nil {
+			return nil, err
+		}
+		return &PaymentTokenResponse{PaymentResponse: *recovered}, nil
+	}
+
+	var resp PaymentTokenResponse
+	if err = json.Unmarshal(rawResp, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, c.checkStatus(servicePayByToken, "", rawResp)
 }
@@ -44,3 +44,62 @@ func TestPayByPrime(t *testing.T) {
 
 	}
 }
+
+func TestPayByToken(t *testing.T) {
+	for _, tc := range []struct {
+		name           string
+		params         PaymentTokenParams
+		respBody       string
+		wantStatus     int
+		wantRecTradeID string
+	}{
+		{
+			name: "Given valid card secret returns status 0",
+			params: PaymentTokenParams{
+				CardKey:    "card_key",
+				CardToken:  "card_token",
+				MerchantID: "GlobalTesting_CTBC",
+				Amount:     100,
+				Currency:   "TWD",
+				Details:    "test-tappay-go-package",
+			},
+			respBody:       `{"status":0,"msg":"Success","rec_trade_id":"D20200101abc","amount":100,"currency":"TWD","card_info":{"last_four":"4242","expiry_date":"202512"}}`,
+			wantStatus:     0,
+			wantRecTradeID: "D20200101abc",
+		},
+		{
+			name: "Given revoked card secret returns non-zero status",
+			params: PaymentTokenParams{
+				CardKey:    "revoked_key",
+				CardToken:  "revoked_token",
+				MerchantID: "GlobalTesting_CTBC",
+				Amount:     100,
+				Currency:   "TWD",
+				Details:    "test-tappay-go-package",
+			},
+			respBody:   `{"status":10003,"msg":"Card Error"}`,
+			wantStatus: 10003,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var params map[string]interface{}
+			cli := newTestClient(t, cannedResponse(t, payByTokenPath, &params, tc.respBody))
+			resp, err := cli.PayByToken(context.Background(), tc.params)
+			if err != nil {
+				t.Fatalf("unexpected pay-by-token error, err: %v", err)
+			}
+			if params["card_key"] != tc.params.CardKey || params["card_token"] != tc.params.CardToken {
+				t.Errorf("expected card secret (%s, %s) in request, got: (%v, %v)", tc.params.CardKey, tc.params.CardToken, params["card_key"], params["card_token"])
+			}
+			if params["partner_key"] != "tappay_key" {
+				t.Errorf("expected partner_key in request, got: %v", params["partner_key"])
+			}
+			if tc.wantStatus != resp.Status {
+				t.Errorf("expected pay-by-token status: %d, got :%d", tc.wantStatus, resp.Status)
+			}
+			if tc.wantRecTradeID != resp.RecTradeID {
+				t.Errorf("expected rec_trade_id: %s, got :%s", tc.wantRecTradeID, resp.RecTradeID)
+			}
+		})
+	}
+}
@@ -0,0 +1,264 @@
+// Package reconcile matches the orders of the merchant against the TapPay trade records
+// and reports the discrepancies between them.
+package reconcile
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/babygoat/tappay-go"
+)
+
+// Order defines the order expected by the merchant to be paid through TapPay.
+// At least one of OrderNumber, RecTradeID and BankTransactionID should be set to match the record.
+type Order struct {
+	OrderNumber       string
+	RecTradeID        string
+	BankTransactionID string
+	Amount            int
+
+	// Currency defaults to TWD if it is empty
+	Currency tappay.Currency
+
+	// RefundedAmount is the amount expected to be refunded
+	RefundedAmount int
+}
+
+// OrderSource is the interface implemented by the source of the expected orders
+type OrderSource interface {
+	Orders(ctx context.Context) ([]Order, error)
+}
+
+// Orders implements the OrderSource with a slice of orders
+type Orders []Order
+
+// Orders implements the OrderSource interface
+func (o Orders) Orders(ctx context.Context) ([]Order, error) {
+	return o, nil
+}
+
+// OrderSourceFunc adapts the function into OrderSource
+type OrderSourceFunc func(ctx context.Context) ([]Order, error)
+
+// Orders implements the OrderSource interface
+func (f OrderSourceFunc) Orders(ctx context.Context) ([]Order, error) {
+	return f(ctx)
+}
+
+// Kind denotes the category of the discrepancy
+type Kind int
+
+const (
+	// KindMissingTrade denotes the order has no matching record
+	KindMissingTrade Kind = iota + 1
+	// KindUnexpectedTrade denotes the successful record has no matching order
+	KindUnexpectedTrade
+	// KindAmountMismatch denotes the amount of the record differs from the order
+	KindAmountMismatch
+	// KindCurrencyMismatch denotes the currency of the record differs from the order
+	KindCurrencyMismatch
+	// KindUnexpectedRefund denotes the record is fully refunded but the order expects otherwise
+	KindUnexpectedRefund
+	// KindUnexpectedPartialRefund denotes the record is partially refunded but the order expects otherwise
+	KindUnexpectedPartialRefund
+	// KindRefundMismatch denotes the refunded amount of the record differs from the order
+	KindRefundMismatch
+	// KindUncaptured denotes the record is authorized but not captured yet
+	KindUncaptured
+	// KindPending denotes the record is still pending, e.g. waiting for 3D secure authentication
+	KindPending
+	// KindFailedTrade denotes the matching record is failed or cancelled
+	KindFailedTrade
+)
+
+var kindNames = map[Kind]string{
+	KindMissingTrade:            "missing_trade",
+	KindUnexpectedTrade:         "unexpected_trade",
+	KindAmountMismatch:          "amount_mismatch",
+	KindCurrencyMismatch:        "currency_mismatch",
+	KindUnexpectedRefund:        "unexpected_refund",
+	KindUnexpectedPartialRefund: "unexpected_partial_refund",
+	KindRefundMismatch:          "refund_mismatch",
+	KindUncaptured:              "uncaptured",
+	KindPending:                 "pending",
+	KindFailedTrade:             "failed_trade",
+}
+
+// String implements the fmt.Stringer interface
+func (k Kind) String() string {
+	if name, ok := kindNames[k]; ok {
+		return name
+	}
+	return fmt.Sprintf("kind(%d)", int(k))
+}
+
+// Discrepancy defines a mismatch between the order and the record.
+// Order is nil for KindUnexpectedTrade and Record is nil for KindMissingTrade.
+type Discrepancy struct {
+	Kind   Kind
+	Order  *Order
+	Record *tappay.Record
+	Detail string
+}
+
+// Match defines the order and its matching record
+type Match struct {
+	Order  Order
+	Record tappay.Record
+}
+
+// Report defines the result of the reconciliation
+type Report struct {
+	// Matches are the orders with matching records, regardless of the discrepancies
+	Matches []Match
+
+	// Discrepancies are ordered by the orders, followed by the unexpected trades in the order of records
+	Discrepancies []Discrepancy
+}
+
+// ByKind returns the discrepancies of the kind
+func (r *Report) ByKind(kind Kind) []Discrepancy {
+	var ds []Discrepancy
+	for _, d := range r.Discrepancies {
+		if d.Kind == kind {
+			ds = append(ds, d)
+		}
+	}
+	return ds
+}
+
+// OK reports whether there is no discrepancy
+func (r *Report) OK() bool {
+	return len(r.Discrepancies) == 0
+}
+
+// Reconcile matches the orders against the records by RecTradeID, BankTransactionID and then
+// OrderNumber, and reports the discrepancies. When several records share the order number, e.g. a
+// failed attempt followed by a successful one, the successful record is preferred. The unmatched
+// failed records are not reported since no money is moved.
+func Reconcile(ctx context.Context, orders OrderSource, records tappay.RecordSource) (*Report, error) {
+	expected, err := orders.Orders(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get the orders, err: %v", err)
+	}
+
+	var all []*tappay.Record
+	byRecTradeID := make(map[string]*tappay.Record)
+	byBankTransactionID := make(map[string]*tappay.Record)
+	byOrderNumber := make(map[string][]*tappay.Record)
+	for records.Next(ctx) {
+		r := records.Record()
+		all = append(all, &r)
+		byRecTradeID[r.RecTradeID] = &r
+		if r.BankTransactionID != "" {
+			byBankTransactionID[r.BankTransactionID] = &r
+		}
+		if r.OrderNumber != "" {
+			byOrderNumber[r.OrderNumber] = append(byOrderNumber[r.OrderNumber], &r)
+		}
+	}
+	if err := records.Err(); err != nil {
+		return nil, fmt.Errorf("cannot get the records, err: %v", err)
+	}
+
+	report := &Report{}
+	matched := make(map[*tappay.Record]bool)
+	for i := range expected {
+		o := &expected[i]
+		r := match(o, byRecTradeID, byBankTransactionID, byOrderNumber, matched)
+		if r == nil {
+			report.Discrepancies = append(report.Discrepancies, Discrepancy{
+				Kind:   KindMissingTrade,
+				Order:  o,
+				Detail: "no matching record",
+			})
+			continue
+		}
+		matched[r] = true
+		report.Matches = append(report.Matches, Match{Order: *o, Record: *r})
+		report.Discrepancies = append(report.Discrepancies, compare(o, r)...)
+	}
+
+	for _, r := range all {
+		if !matched[r] && !failed(r) {
+			report.Discrepancies = append(report.Discrepancies, Discrepancy{
+				Kind:   KindUnexpectedTrade,
+				Record: r,
+				Detail: "no matching order",
+			})
+		}
+	}
+	return report, nil
+}
+
+// match returns the record matching the order which has not been matched yet, or nil if not found
+func match(o *Order, byRecTradeID, byBankTransactionID map[string]*tappay.Record, byOrderNumber map[string][]*tappay.Record, matched map[*tappay.Record]bool) *tappay.Record {
+	if r, ok := byRecTradeID[o.RecTradeID]; ok && o.RecTradeID != "" && !matched[r] {
+		return r
+	}
+	if r, ok := byBankTransactionID[o.BankTransactionID]; ok && o.BankTransactionID != "" && !matched[r] {
+		return r
+	}
+	if o.OrderNumber == "" {
+		return nil
+	}
+	var candidate *tappay.Record
+	for _, r := range byOrderNumber[o.OrderNumber] {
+		if matched[r] {
+			continue
+		}
+		if !failed(r) {
+			return r
+		}
+		if candidate == nil {
+			candidate = r
+		}
+	}
+	return candidate
+}
+
+// failed reports whether the record is failed or cancelled
+func failed(r *tappay.Record) bool {
+	return r.RecordStatus == tappay.RecordStatusError || r.RecordStatus == tappay.RecordStatusCancel
+}
+
+// compare returns the discrepancies between the order and its matching record
+func compare(o *Order, r *tappay.Record) []Discrepancy {
+	var ds []Discrepancy
+	add := func(kind Kind, format string, args ...interface{}) {
+		ds = append(ds, Discrepancy{Kind: kind, Order: o, Record: r, Detail: fmt.Sprintf(format, args...)})
+	}
+
+	switch r.RecordStatus {
+	case tappay.RecordStatusError, tappay.RecordStatusCancel:
+		add(KindFailedTrade, "record_status %v", r.RecordStatus)
+		return ds
+	case tappay.RecordStatusPending:
+		add(KindPending, "record_status %v", r.RecordStatus)
+		return ds
+	case tappay.RecordStatusAuth:
+		add(KindUncaptured, "authorized but not captured")
+	}
+
+	if r.Amount != o.Amount {
+		add(KindAmountMismatch, "expected amount %d, got %d", o.Amount, r.Amount)
+	}
+	currency := o.Currency
+	if currency == "" {
+		currency = tappay.DefaultCurrency
+	}
+	if r.Currency != "" && r.Currency != currency {
+		add(KindCurrencyMismatch, "expected currency %s, got %s", currency, r.Currency)
+	}
+	if r.RefundedAmount != o.RefundedAmount {
+		switch {
+		case o.RefundedAmount == 0 && r.RecordStatus == tappay.RecordStatusRefunded:
+			add(KindUnexpectedRefund, "refunded %d", r.RefundedAmount)
+		case o.RefundedAmount == 0 && r.RecordStatus == tappay.RecordStatusPartialRefunded:
+			add(KindUnexpectedPartialRefund, "partially refunded %d", r.RefundedAmount)
+		default:
+			add(KindRefundMismatch, "expected refunded amount %d, got %d", o.RefundedAmount, r.RefundedAmount)
+		}
+	}
+	return ds
+}
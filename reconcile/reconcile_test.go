@@ -0,0 +1,138 @@
+package reconcile
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/babygoat/tappay-go"
+)
+
+// recordSlice implements tappay.RecordSource over a slice of records
+type recordSlice struct {
+	records []tappay.Record
+	record  tappay.Record
+}
+
+func (s *recordSlice) Next(ctx context.Context) bool {
+	if len(s.records) == 0 {
+		return false
+	}
+	s.record, s.records = s.records[0], s.records[1:]
+	return true
+}
+
+func (s *recordSlice) Record() tappay.Record { return s.record }
+
+func (s *recordSlice) Err() error { return nil }
+
+func TestReconcile(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		orders      Orders
+		records     []tappay.Record
+		wantKinds   []Kind
+		wantMatches int
+	}{
+		{
+			name:        "Given matching order number returns no discrepancy",
+			orders:      Orders{{OrderNumber: "order-1", Amount: 100}},
+			records:     []tappay.Record{{RecTradeID: "D1", OrderNumber: "order-1", Amount: 100, Currency: "TWD", RecordStatus: tappay.RecordStatusOK}},
+			wantMatches: 1,
+		},
+		{
+			name:        "Given matching rec_trade_id and bank_transaction_id returns no discrepancy",
+			orders:      Orders{{RecTradeID: "D1", Amount: 100}, {BankTransactionID: "TP2", Amount: 200}},
+			records:     []tappay.Record{{RecTradeID: "D1", Amount: 100, RecordStatus: tappay.RecordStatusOK}, {RecTradeID: "D2", BankTransactionID: "TP2", Amount: 200, RecordStatus: tappay.RecordStatusOK}},
+			wantMatches: 2,
+		},
+		{
+			name:      "Given order without record returns missing trade",
+			orders:    Orders{{OrderNumber: "order-1", Amount: 100}},
+			wantKinds: []Kind{KindMissingTrade},
+		},
+		{
+			name:      "Given record without order returns unexpected trade",
+			records:   []tappay.Record{{RecTradeID: "D1", OrderNumber: "order-1", Amount: 100, RecordStatus: tappay.RecordStatusOK}},
+			wantKinds: []Kind{KindUnexpectedTrade},
+		},
+		{
+			name:    "Given unmatched failed record returns no discrepancy",
+			records: []tappay.Record{{RecTradeID: "D1", OrderNumber: "order-1", Amount: 100, RecordStatus: tappay.RecordStatusError}},
+		},
+		{
+			name:        "Given different amount and currency returns mismatches",
+			orders:      Orders{{OrderNumber: "order-1", Amount: 100, Currency: "TWD"}},
+			records:     []tappay.Record{{RecTradeID: "D1", OrderNumber: "order-1", Amount: 90, Currency: "USD", RecordStatus: tappay.RecordStatusOK}},
+			wantKinds:   []Kind{KindAmountMismatch, KindCurrencyMismatch},
+			wantMatches: 1,
+		},
+		{
+			name:   "Given refunded records returns unexpected refunds",
+			orders: Orders{{OrderNumber: "order-1", Amount: 100}, {OrderNumber: "order-2", Amount: 100}, {OrderNumber: "order-3", Amount: 100, RefundedAmount: 50}},
+			records: []tappay.Record{
+				{RecTradeID: "D1", OrderNumber: "order-1", Amount: 100, RefundedAmount: 100, RecordStatus: tappay.RecordStatusRefunded},
+				{RecTradeID: "D2", OrderNumber: "order-2", Amount: 100, RefundedAmount: 30, RecordStatus: tappay.RecordStatusPartialRefunded},
+				{RecTradeID: "D3", OrderNumber: "order-3", Amount: 100, RefundedAmount: 30, RecordStatus: tappay.RecordStatusPartialRefunded},
+			},
+			wantKinds:   []Kind{KindUnexpectedRefund, KindUnexpectedPartialRefund, KindRefundMismatch},
+			wantMatches: 3,
+		},
+		{
+			name:   "Given authorized and pending records returns uncaptured and pending",
+			orders: Orders{{OrderNumber: "order-1", Amount: 100}, {OrderNumber: "order-2", Amount: 100}},
+			records: []tappay.Record{
+				{RecTradeID: "D1", OrderNumber: "order-1", Amount: 100, RecordStatus: tappay.RecordStatusAuth},
+				{RecTradeID: "D2", OrderNumber: "order-2", Amount: 100, RecordStatus: tappay.RecordStatusPending},
+			},
+			wantKinds:   []Kind{KindUncaptured, KindPending},
+			wantMatches: 2,
+		},
+		{
+			name:   "Given failed attempt before successful one matches the successful record",
+			orders: Orders{{OrderNumber: "order-1", Amount: 100}},
+			records: []tappay.Record{
+				{RecTradeID: "D1", OrderNumber: "order-1", Amount: 100, RecordStatus: tappay.RecordStatusError},
+				{RecTradeID: "D2", OrderNumber: "order-1", Amount: 100, RecordStatus: tappay.RecordStatusOK},
+			},
+			wantMatches: 1,
+		},
+		{
+			name:        "Given failed record only returns failed trade",
+			orders:      Orders{{OrderNumber: "order-1", Amount: 100}},
+			records:     []tappay.Record{{RecTradeID: "D1", OrderNumber: "order-1", Amount: 100, RecordStatus: tappay.RecordStatusError}},
+			wantKinds:   []Kind{KindFailedTrade},
+			wantMatches: 1,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			report, err := Reconcile(context.Background(), tc.orders, &recordSlice{records: tc.records})
+			if err != nil {
+				t.Fatalf("unexpected reconcile error: %v", err)
+			}
+			var kinds []Kind
+			for _, d := range report.Discrepancies {
+				kinds = append(kinds, d.Kind)
+			}
+			if !reflect.DeepEqual(kinds, tc.wantKinds) {
+				t.Errorf("expected discrepancies: %v, got: %v", tc.wantKinds, kinds)
+			}
+			if len(report.Matches) != tc.wantMatches {
+				t.Errorf("expected %d matches, got: %d", tc.wantMatches, len(report.Matches))
+			}
+			if report.OK() != (len(tc.wantKinds) == 0) {
+				t.Errorf("unexpected OK: %t", report.OK())
+			}
+		})
+	}
+}
+
+func TestReconcileOrderSourceError(t *testing.T) {
+	source := OrderSourceFunc(func(ctx context.Context) ([]Order, error) {
+		return nil, errors.New("database is down")
+	})
+	if _, err := Reconcile(context.Background(), source, &recordSlice{}); err == nil {
+		t.Errorf("expected error of the order source")
+	}
+}
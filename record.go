@@ -7,6 +7,8 @@ import (
 	"net/http"
 )
 
+// RecordStatus denotes the `record_status` field of the record
+// It is encoded as the number in JSON, where the unknown values are preserved.
 type RecordStatus int
 
 const (
@@ -19,6 +21,27 @@ const (
 	RecordStatusCancel
 )
 
+var recordStatusNames = map[int]string{
+	int(RecordStatusError):           "error",
+	int(RecordStatusAuth):            "auth",
+	int(RecordStatusOK):              "ok",
+	int(RecordStatusPartialRefunded): "partial_refunded",
+	int(RecordStatusRefunded):        "refunded",
+	int(RecordStatusPending):         "pending",
+	int(RecordStatusCancel):          "cancel",
+}
+
+// String implements the fmt.Stringer interface
+func (s RecordStatus) String() string {
+	return enumName(int(s), recordStatusNames)
+}
+
+// Valid reports whether the record status is defined by TapPay
+func (s RecordStatus) Valid() bool {
+	_, ok := recordStatusNames[int(s)]
+	return ok
+}
+
 // recordPath defines the path of record query service
 const recordPath = "/tpc/transaction/query"
 
@@ -41,17 +64,18 @@ type RecordFilterCardholder struct {
 	Email       string `json:"email,omitempty"`
 }
 
-// RecordFilters defines a collection of filters that can be used within the records query operation
+// RecordFilters defines a collection of filters that can be used within the records query operation.
+// RecordStatus is a pointer so that RecordStatusAuth, i.e. zero, can be told apart from no filter.
 type RecordFilters struct {
 	Time              *RecordFilterTime       `json:"time,omitempty"`
 	Amount            *RecordFilterAmount     `json:"amount,omitempty"`
 	Cardholder        *RecordFilterCardholder `json:"cardholder,omitempty"`
 	MerchantID        []string                `json:"merchant_id,omitempty"`
-	RecordStatus      int                     `json:"record_status,omitempty"`
+	RecordStatus      *RecordStatus           `json:"record_status,omitempty"`
 	RecTradeID        string                  `json:"rec_trade_id,omitempty"`
 	OrderNumber       string                  `json:"order_number,omitempty"`
 	BankTransactionID string                  `json:"bank_transaction_id,omitempty"`
-	Currency          string                  `json:"currency,omitempty"`
+	Currency          Currency                `json:"currency,omitempty"`
 }
 
 // RecordSort defines the field(time or amount) and type(ascending/descending) of the returned records
@@ -105,10 +129,10 @@ type RecordMerchantReferenceInfo struct {
 // RecordEInvoiceCarrier defines the `e_invoice_info` field in Record.
 // See Record for more details.
 type RecordEInvoiceCarrier struct {
-	Type       int    `json:"type"`
-	Number     string `json:"number"`
-	Donation   bool   `json:"donation"`
-	DonationID string `json:"donation_id"`
+	Type       EInvoiceCarrierType `json:"type"`
+	Number     string              `json:"number"`
+	Donation   bool                `json:"donation"`
+	DonationID string              `json:"donation_id"`
 }
 
 // RecordInstalmentInfo defines the `instalment_info` field in Record.
@@ -143,16 +167,16 @@ type RecordRedeemInfo struct {
 // RecordCardInfo defines the `card_info` field in Record.
 // See Record for more details.
 type RecordCardInfo struct {
-	BinCode     string `json:"bin_code"`
-	LastFour    string `json:"last_four"`
-	Issuer      string `json:"issuer"`
-	IssuerZhTw  string `json:"issuer_zh_tw"`
-	BankID      string `json:"bank_id"`
-	Funding     int    `json:"funding"`
-	Type        int    `json:"type"`
-	Level       string `json:"level"`
-	Country     string `json:"country"`
-	CountryCode string `json:"country_code"`
+	BinCode     string      `json:"bin_code"`
+	LastFour    string      `json:"last_four"`
+	Issuer      string      `json:"issuer"`
+	IssuerZhTw  string      `json:"issuer_zh_tw"`
+	BankID      string      `json:"bank_id"`
+	Funding     CardFunding `json:"funding"`
+	Type        CardType    `json:"type"`
+	Level       string      `json:"level"`
+	Country     string      `json:"country"`
+	CountryCode string      `json:"country_code"`
 }
 
 // Record defines trade record returned from TapPay server after record query
@@ -176,11 +200,11 @@ type Record struct {
 	BankResultCode             string                      `json:"bank_result_code"`
 	BankResultMsg              string                      `json:"bank_result_msg"`
 	PartialCardNumber          string                      `json:"partial_card_number"`
-	PaymentMethod              string                      `json:"payment_method"`
+	PaymentMethod              PaymentMethod               `json:"payment_method"`
 	Details                    string                      `json:"details"`
 	Cardholder                 RecordCardholder            `json:"cardholder"`
 	MerchandiseDetails         RecordMerchandiseDetails    `json:"merchandise_details"`
-	Currency                   string                      `json:"currency"`
+	Currency                   Currency                    `json:"currency"`
 	MerchantReferenceInfo      RecordMerchantReferenceInfo `json:"merchant_reference_info"`
 	EInvoiceCarrier            RecordEInvoiceCarrier       `json:"e_invoice_carrier"`
 	ThreeDomainSecure          bool                        `json:"three_domain_secure"`
@@ -224,5 +248,5 @@ func (c *client) Records(ctx context.Context, params RecordParams) (*RecordRespo
 		return nil, err
 	}
 
-	return &resp, nil
+	return &resp, c.checkStatus(serviceRecord, "", rawResp)
 }
@@ -0,0 +1,135 @@
+package tappay
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// defaultBulkConcurrency is the default number of pages fetched concurrently by RecordsBulk
+const defaultBulkConcurrency = 4
+
+// RecordsPageError reports the failure of fetching a page in RecordsBulk
+type RecordsPageError struct {
+	Page int
+	Err  error
+}
+
+// Error implements the error interface
+func (e *RecordsPageError) Error() string {
+	return fmt.Sprintf("tappay: cannot fetch records of page %d: %v", e.Page, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *RecordsPageError) Unwrap() error {
+	return e.Err
+}
+
+// RecordsBulkResult defines the result of RecordsBulk
+type RecordsBulkResult struct {
+	// Records are the records of all the fetched pages in the order of RecordSort
+	Records              []Record
+	TotalPageCount       int
+	NumberOfTransactions int64
+
+	// Errors are the failures of the pages which are missing from Records, ordered by page
+	Errors []*RecordsPageError
+}
+
+// RecordsBulk fetches the records of all the pages matching the params, starting from params.Page.
+// After learning the total page count from the first page, the remaining pages are fetched with
+// at most concurrency requests in flight, or 4 if concurrency is not positive.
+// The error is returned only if the first page cannot be fetched. The failures of the remaining
+// pages are reported in RecordsBulkResult.Errors.
+func (c *client) RecordsBulk(ctx context.Context, params RecordParams, concurrency int) (*RecordsBulkResult, error) {
+	if concurrency <= 0 {
+		concurrency = defaultBulkConcurrency
+	}
+	params = pinRecordParams(params, time.Now())
+
+	first, err := c.recordsPage(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	result := &RecordsBulkResult{
+		TotalPageCount:       first.TotalPageCount,
+		NumberOfTransactions: first.NumberOfTransactions,
+	}
+
+	// pages[i] holds the records of page params.Page+i
+	// rest is the number of the remaining pages, which is none if params.Page is past the last page
+	rest := first.TotalPageCount - params.Page - 1
+	if rest < 0 {
+		rest = 0
+	}
+	pages := make([][]Record, rest+1)
+	pages[0] = first.TradeRecords
+	pageErrors := make(map[int]error)
+	if rest > 0 {
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		indices := make(chan int)
+		for w := 0; w < concurrency && w < rest; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range indices {
+					p := params
+					p.Page += i
+					resp, err := c.recordsPage(ctx, p)
+
+					mu.Lock()
+					if err != nil {
+						pageErrors[p.Page] = err
+					} else {
+						pages[i] = resp.TradeRecords
+					}
+					mu.Unlock()
+				}
+			}()
+		}
+		for i := 1; i <= rest; i++ {
+			indices <- i
+		}
+		close(indices)
+		wg.Wait()
+	}
+
+	// skips the records shifted into the following pages
+	seen := make(map[string]bool)
+	for i, records := range pages {
+		if err, ok := pageErrors[params.Page+i]; ok {
+			result.Errors = append(result.Errors, &RecordsPageError{Page: params.Page + i, Err: err})
+			continue
+		}
+		for _, r := range records {
+			if seen[r.RecTradeID] {
+				continue
+			}
+			seen[r.RecTradeID] = true
+			result.Records = append(result.Records, r)
+		}
+	}
+	return result, nil
+}
+
+// recordsPage queries a page of records, returning an APIError for the failure status
+// and an empty page for StatusNoRecord
+func (c *client) recordsPage(ctx context.Context, params RecordParams) (*RecordResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	resp, err := c.Records(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	switch resp.StatusCode() {
+	case StatusSuccess:
+	case StatusNoRecord:
+		resp.TradeRecords = nil
+	default:
+		return nil, &APIError{Status: resp.Status, Msg: resp.Msg, Service: string(serviceRecord)}
+	}
+	return resp, nil
+}
@@ -0,0 +1,122 @@
+package tappay_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"sort"
+	"testing"
+
+	"github.com/babygoat/tappay-go"
+)
+
+func TestRecordsBulk(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		records     int
+		params      tappay.RecordParams
+		concurrency int
+		wantCount   int
+		less        func(a, b tappay.Record) bool
+	}{
+		{
+			name:        "Given records across pages returns all records in descending time",
+			records:     23,
+			params:      tappay.RecordParams{RecordsPerPage: 5},
+			concurrency: 3,
+			wantCount:   23,
+			less:        func(a, b tappay.Record) bool { return a.Time > b.Time },
+		},
+		{
+			name:        "Given ascending time order returns all records in ascending time",
+			records:     23,
+			params:      tappay.RecordParams{RecordsPerPage: 5, OrderBy: &tappay.RecordSort{Attribute: "time"}},
+			concurrency: 10,
+			wantCount:   23,
+			less:        func(a, b tappay.Record) bool { return a.Time < b.Time },
+		},
+		{
+			name:    "Given no record returns nothing",
+			records: 0,
+			params:  tappay.RecordParams{RecordsPerPage: 5},
+		},
+		{
+			name:        "Given starting page past the last page returns nothing",
+			records:     7,
+			params:      tappay.RecordParams{RecordsPerPage: 5, Page: 3},
+			concurrency: 2,
+		},
+		{
+			name:        "Given no record from non-zero page returns nothing",
+			records:     0,
+			params:      tappay.RecordParams{RecordsPerPage: 5, Page: 1},
+			concurrency: 2,
+		},
+		{
+			name:        "Given starting page returns the records of the remaining pages",
+			records:     23,
+			params:      tappay.RecordParams{RecordsPerPage: 5, Page: 3},
+			concurrency: 2,
+			wantCount:   8,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := newFakeServer(t, tc.records)
+			cli, _ := tappay.NewClient(testPartnerKey, tappay.WithServer(srv.URL))
+
+			result, err := cli.RecordsBulk(context.Background(), tc.params, tc.concurrency)
+			if err != nil {
+				t.Fatalf("unexpected bulk error: %v", err)
+			}
+			if len(result.Errors) != 0 {
+				t.Errorf("unexpected page errors: %v", result.Errors)
+			}
+			if len(result.Records) != tc.wantCount {
+				t.Errorf("expected %d records, got: %d", tc.wantCount, len(result.Records))
+			}
+			if tc.less != nil && !sort.SliceIsSorted(result.Records, func(i, j int) bool { return tc.less(result.Records[i], result.Records[j]) }) {
+				t.Errorf("expected records in the requested order")
+			}
+		})
+	}
+}
+
+func TestRecordsBulkPartialFailure(t *testing.T) {
+	srv := newFakeServer(t, 12)
+	target, _ := url.Parse(srv.URL)
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		var params tappay.RecordParams
+		json.Unmarshal(body, &params)
+		if params.Page == 1 {
+			http.Error(w, "bad gateway", http.StatusBadGateway)
+			return
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		proxy.ServeHTTP(w, r)
+	}))
+	t.Cleanup(failing.Close)
+
+	cli, _ := tappay.NewClient(testPartnerKey, tappay.WithServer(failing.URL))
+	result, err := cli.RecordsBulk(context.Background(), tappay.RecordParams{RecordsPerPage: 5}, 2)
+	if err != nil {
+		t.Fatalf("unexpected bulk error: %v", err)
+	}
+	if len(result.Records) != 7 {
+		t.Errorf("expected records of page 0 and 2, got: %d", len(result.Records))
+	}
+	if len(result.Errors) != 1 || result.Errors[0].Page != 1 {
+		t.Fatalf("expected error of page 1, got: %v", result.Errors)
+	}
+	var httpErr *tappay.HTTPError
+	if !errors.As(result.Errors[0], &httpErr) || httpErr.StatusCode != http.StatusBadGateway {
+		t.Errorf("expected HTTPError of bad gateway, got: %v", result.Errors[0])
+	}
+}
@@ -0,0 +1,129 @@
+package tappay
+
+import (
+	"context"
+	"time"
+)
+
+// RecordSource is the interface implemented by the iterators of records, e.g. RecordsIterator.
+// It is accepted by the consumers of the records, e.g. package export and reconcile.
+type RecordSource interface {
+	Next(ctx context.Context) bool
+	Record() Record
+	Err() error
+}
+
+// RecordsIterator walks through the records of all the pages matching the RecordParams.
+// It is created by client.RecordsIterator and used as
+//
+//	it := cli.RecordsIterator(params)
+//	for it.Next(ctx) {
+//		record := it.Record()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+//
+// To guard against the records shifting between pages while iterating, the end of the time filter
+// is pinned to the time of the first query if it is not set, and the records already returned
+// in the previous pages are skipped.
+type RecordsIterator struct {
+	client *client
+	params RecordParams
+
+	records []Record
+	record  Record
+	seen    map[string]bool
+	started bool
+	done    bool
+	err     error
+}
+
+// RecordsIterator returns a RecordsIterator which walks through the records matching the params,
+// starting from params.Page
+func (c *client) RecordsIterator(params RecordParams) *RecordsIterator {
+	return &RecordsIterator{
+		client: c,
+		params: params,
+		seen:   make(map[string]bool),
+	}
+}
+
+// Next advances the iterator to the next record, fetching the next page if needed.
+// It returns false when there is no more record or an error occurs, which is reported by Err.
+func (it *RecordsIterator) Next(ctx context.Context) bool {
+	for it.err == nil {
+		if err := ctx.Err(); err != nil {
+			it.err = err
+			return false
+		}
+
+		if len(it.records) > 0 {
+			r := it.records[0]
+			it.records = it.records[1:]
+			if it.seen[r.RecTradeID] {
+				continue
+			}
+			it.seen[r.RecTradeID] = true
+			it.record = r
+			return true
+		}
+
+		if it.done {
+			return false
+		}
+		it.fetch(ctx)
+	}
+	return false
+}
+
+// Record returns the current record of the iterator
+func (it *RecordsIterator) Record() Record {
+	return it.record
+}
+
+// Err returns the error, if any, that was encountered during iteration
+func (it *RecordsIterator) Err() error {
+	return it.err
+}
+
+// fetch queries the next page of records
+func (it *RecordsIterator) fetch(ctx context.Context) {
+	if !it.started {
+		it.params = pinRecordParams(it.params, time.Now())
+		it.started = true
+	} else {
+		it.params.Page++
+	}
+
+	resp, err := it.client.recordsPage(ctx, it.params)
+	if err != nil {
+		it.err = err
+		return
+	}
+
+	it.records = resp.TradeRecords
+	if len(resp.TradeRecords) == 0 || it.params.Page+1 >= resp.TotalPageCount {
+		it.done = true
+	}
+}
+
+// pinRecordParams returns a copy of params with the end of the time filter set to now if it is not set,
+// so that the transactions created during the query do not shift the records between pages
+func pinRecordParams(params RecordParams, now time.Time) RecordParams {
+	var filters RecordFilters
+	if params.Filters != nil {
+		filters = *params.Filters
+	}
+	var filterTime RecordFilterTime
+	if filters.Time != nil {
+		filterTime = *filters.Time
+	}
+	if filterTime.EndTime == 0 {
+		filterTime.EndTime = timeToMillis(now)
+	}
+	filters.Time = &filterTime
+	params.Filters = &filters
+	return params
+}
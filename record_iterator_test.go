@@ -0,0 +1,141 @@
+package tappay_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/babygoat/tappay-go"
+	"github.com/babygoat/tappay-go/tappaytest"
+)
+
+const testPartnerKey = "partner_tappaytest"
+
+// newFakeServer starts a fake TapPay server with n transactions created one minute apart
+func newFakeServer(t *testing.T, n int) *tappaytest.Server {
+	t.Helper()
+
+	srv := tappaytest.NewServer(testPartnerKey)
+	t.Cleanup(srv.Close)
+	base := time.Now().Add(-time.Duration(n) * time.Minute)
+	for i := 0; i < n; i++ {
+		srv.AddRecord(tappay.Record{
+			RecTradeID:   fmt.Sprintf("D%04d", i),
+			OrderNumber:  fmt.Sprintf("order-%d", i),
+			Time:         base.Add(time.Duration(i)*time.Minute).UnixNano() / int64(time.Millisecond),
+			Amount:       100,
+			RecordStatus: tappay.RecordStatusOK,
+			Currency:     "TWD",
+		})
+	}
+	return srv
+}
+
+func TestRecordsIterator(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		records   int
+		params    tappay.RecordParams
+		wantCount int
+	}{
+		{
+			name:      "Given no record returns nothing",
+			records:   0,
+			params:    tappay.RecordParams{RecordsPerPage: 2},
+			wantCount: 0,
+		},
+		{
+			name:      "Given records across pages returns all records",
+			records:   7,
+			params:    tappay.RecordParams{RecordsPerPage: 2},
+			wantCount: 7,
+		},
+		{
+			name:      "Given starting page skips the previous pages",
+			records:   7,
+			params:    tappay.RecordParams{RecordsPerPage: 2, Page: 2},
+			wantCount: 3,
+		},
+		{
+			name:      "Given filter returns the matched records only",
+			records:   7,
+			params:    tappay.RecordParams{RecordsPerPage: 2, Filters: &tappay.RecordFilters{OrderNumber: "order-3"}},
+			wantCount: 1,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := newFakeServer(t, tc.records)
+			cli, _ := tappay.NewClient(testPartnerKey, tappay.WithServer(srv.URL))
+
+			it := cli.RecordsIterator(tc.params)
+			seen := map[string]bool{}
+			for it.Next(context.Background()) {
+				if seen[it.Record().RecTradeID] {
+					t.Errorf("unexpected duplicated record: %s", it.Record().RecTradeID)
+				}
+				seen[it.Record().RecTradeID] = true
+			}
+			if err := it.Err(); err != nil {
+				t.Fatalf("unexpected iteration error: %v", err)
+			}
+			if len(seen) != tc.wantCount {
+				t.Errorf("expected %d records, got: %d", tc.wantCount, len(seen))
+			}
+		})
+	}
+}
+
+func TestRecordsIteratorShiftedRecords(t *testing.T) {
+	srv := newFakeServer(t, 6)
+	cli, _ := tappay.NewClient(testPartnerKey, tappay.WithServer(srv.URL))
+
+	it := cli.RecordsIterator(tappay.RecordParams{RecordsPerPage: 2})
+	var count int
+	for it.Next(context.Background()) {
+		count++
+		if count == 1 {
+			// a new transaction arrives during iteration and would shift the records in descending time
+			srv.AddRecord(tappay.Record{RecTradeID: "D_new", Time: time.Now().Add(time.Minute).UnixNano() / int64(time.Millisecond)})
+		}
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("unexpected iteration error: %v", err)
+	}
+	if count != 6 {
+		t.Errorf("expected 6 records, got: %d", count)
+	}
+}
+
+func TestRecordsIteratorCancel(t *testing.T) {
+	srv := newFakeServer(t, 6)
+	cli, _ := tappay.NewClient(testPartnerKey, tappay.WithServer(srv.URL))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	it := cli.RecordsIterator(tappay.RecordParams{RecordsPerPage: 2})
+	if !it.Next(ctx) {
+		t.Fatalf("expected the first record, got error: %v", it.Err())
+	}
+	cancel()
+	if it.Next(ctx) {
+		t.Errorf("expected iteration stopped after cancellation")
+	}
+	if !errors.Is(it.Err(), context.Canceled) {
+		t.Errorf("expected context.Canceled, got: %v", it.Err())
+	}
+}
+
+func TestRecordsIteratorAPIError(t *testing.T) {
+	srv := newFakeServer(t, 1)
+	cli, _ := tappay.NewClient("wrong_key", tappay.WithServer(srv.URL))
+
+	it := cli.RecordsIterator(tappay.RecordParams{})
+	if it.Next(context.Background()) {
+		t.Errorf("expected no record with wrong partner key")
+	}
+	var apiErr *tappay.APIError
+	if !errors.As(it.Err(), &apiErr) || apiErr.StatusCode() != tappay.StatusAuthenticationFailed {
+		t.Errorf("expected APIError of authentication failure, got: %v", it.Err())
+	}
+}
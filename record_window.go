@@ -0,0 +1,99 @@
+package tappay
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// defaultWindowMaxRecords is the default maximum number of records of a time window in RecordsInWindows
+const defaultWindowMaxRecords = 1000
+
+// RecordsInWindows queries the records matching the params by splitting the time filter recursively
+// into smaller windows until each window has at most maxRecords records, or 1000 if maxRecords is not
+// positive. The end of the time filter defaults to the time of the call.
+//
+// The records are deduplicated by rec_trade_id and streamed to fn window by window, in the order of
+// transaction time given by params.OrderBy, which defaults to descending time. Within a window, the
+// records are in the order of params.OrderBy. The query stops at the first error returned by fn.
+func (c *client) RecordsInWindows(ctx context.Context, params RecordParams, maxRecords int, fn func(Record) error) error {
+	if maxRecords <= 0 {
+		maxRecords = defaultWindowMaxRecords
+	}
+	params = pinRecordParams(params, time.Now())
+	filterTime := *params.Filters.Time
+	if filterTime.StartTime > filterTime.EndTime {
+		return errors.New("tappay: start_time of the time filter is after end_time")
+	}
+
+	w := &recordsWindow{
+		client:     c,
+		params:     params,
+		maxRecords: int64(maxRecords),
+		descending: params.OrderBy == nil || (params.OrderBy.Attribute != "amount" && params.OrderBy.IsDescending),
+		seen:       make(map[string]bool),
+		fn:         fn,
+	}
+	return w.walk(ctx, filterTime.StartTime, filterTime.EndTime)
+}
+
+// recordsWindow holds the state of RecordsInWindows
+type recordsWindow struct {
+	client     *client
+	params     RecordParams
+	maxRecords int64
+	descending bool
+	seen       map[string]bool
+	fn         func(Record) error
+}
+
+// walk streams the records within the window [start, end] in milliseconds, splitting the window
+// into halves if it has more than maxRecords records
+func (w *recordsWindow) walk(ctx context.Context, start, end int64) error {
+	params := w.withWindow(start, end)
+
+	probe := params
+	probe.RecordsPerPage, probe.Page = 1, 0
+	resp, err := w.client.recordsPage(ctx, probe)
+	if err != nil {
+		return err
+	}
+	if resp.NumberOfTransactions == 0 {
+		return nil
+	}
+
+	if resp.NumberOfTransactions > w.maxRecords && start < end {
+		mid := start + (end-start)/2
+		first, second := [2]int64{start, mid}, [2]int64{mid + 1, end}
+		if w.descending {
+			first, second = second, first
+		}
+		if err := w.walk(ctx, first[0], first[1]); err != nil {
+			return err
+		}
+		return w.walk(ctx, second[0], second[1])
+	}
+
+	it := w.client.RecordsIterator(params)
+	for it.Next(ctx) {
+		r := it.Record()
+		if w.seen[r.RecTradeID] {
+			continue
+		}
+		w.seen[r.RecTradeID] = true
+		if err := w.fn(r); err != nil {
+			return err
+		}
+	}
+	return it.Err()
+}
+
+// withWindow returns a copy of the params with the time filter set to [start, end]
+func (w *recordsWindow) withWindow(start, end int64) RecordParams {
+	params := w.params
+	filters := *params.Filters
+	filters.Time = &RecordFilterTime{StartTime: start, EndTime: end}
+	params.Filters = &filters
+	params.Page = 0
+	return params
+}
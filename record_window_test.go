@@ -0,0 +1,97 @@
+package tappay_test
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/babygoat/tappay-go"
+)
+
+func TestRecordsInWindows(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		records    int
+		maxRecords int
+		params     tappay.RecordParams
+		less       func(a, b tappay.Record) bool
+	}{
+		{
+			name:       "Given more records than the window size returns all records in descending time",
+			records:    37,
+			maxRecords: 5,
+			params:     tappay.RecordParams{RecordsPerPage: 2},
+			less:       func(a, b tappay.Record) bool { return a.Time > b.Time },
+		},
+		{
+			name:       "Given ascending time order returns all records in ascending time",
+			records:    37,
+			maxRecords: 5,
+			params:     tappay.RecordParams{RecordsPerPage: 2, OrderBy: &tappay.RecordSort{Attribute: "time"}},
+			less:       func(a, b tappay.Record) bool { return a.Time < b.Time },
+		},
+		{
+			name:       "Given fewer records than the window size returns all records",
+			records:    3,
+			maxRecords: 5,
+			params:     tappay.RecordParams{RecordsPerPage: 2},
+			less:       func(a, b tappay.Record) bool { return a.Time > b.Time },
+		},
+		{
+			name:       "Given no record returns nothing",
+			records:    0,
+			maxRecords: 5,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := newFakeServer(t, tc.records)
+			cli, _ := tappay.NewClient(testPartnerKey, tappay.WithServer(srv.URL))
+
+			var records []tappay.Record
+			seen := map[string]bool{}
+			err := cli.RecordsInWindows(context.Background(), tc.params, tc.maxRecords, func(r tappay.Record) error {
+				if seen[r.RecTradeID] {
+					t.Errorf("unexpected duplicated record: %s", r.RecTradeID)
+				}
+				seen[r.RecTradeID] = true
+				records = append(records, r)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("unexpected query error: %v", err)
+			}
+			if len(records) != tc.records {
+				t.Errorf("expected %d records, got: %d", tc.records, len(records))
+			}
+			if tc.less != nil && !sort.SliceIsSorted(records, func(i, j int) bool { return tc.less(records[i], records[j]) }) {
+				t.Errorf("expected records in the requested order")
+			}
+		})
+	}
+}
+
+func TestRecordsInWindowsStopped(t *testing.T) {
+	srv := newFakeServer(t, 10)
+	cli, _ := tappay.NewClient(testPartnerKey, tappay.WithServer(srv.URL))
+
+	errStop := errors.New("stop")
+	var count int
+	err := cli.RecordsInWindows(context.Background(), tappay.RecordParams{}, 3, func(r tappay.Record) error {
+		count++
+		if count == 4 {
+			return errStop
+		}
+		return nil
+	})
+	if err != errStop || count != 4 {
+		t.Errorf("expected stopped at the 4th record, got count: %d, err: %v", count, err)
+	}
+
+	future := time.Now().Add(time.Hour).UnixNano() / int64(time.Millisecond)
+	err = cli.RecordsInWindows(context.Background(), tappay.RecordParams{Filters: &tappay.RecordFilters{Time: &tappay.RecordFilterTime{StartTime: future}}}, 3, func(tappay.Record) error { return nil })
+	if err == nil {
+		t.Errorf("expected error for start time after end time")
+	}
+}
@@ -33,14 +33,14 @@ func (r RefundParams) MarshalMap() (map[string]interface{}, error) {
 // RefundResponse defines the API response returns by TapPay server after refund request
 // More details in: https://docs.tappaysdk.com/tutorial/zh/back.html#response15
 type RefundResponse struct {
-	Status         int    `json:"status"`
-	Msg            string `json:"msg"`
-	RefundID       string `json:"refund_id"`
-	RefundAmount   int    `json:"refund_amount"`
-	IsCaptured     bool   `json:"is_captured"`
-	BankResultCode string `json:"bank_result_code"`
-	BankResultMsg  string `json:"bank_result_msg"`
-	Currency       string `json:"currency"`
+	Status         int      `json:"status"`
+	Msg            string   `json:"msg"`
+	RefundID       string   `json:"refund_id"`
+	RefundAmount   int      `json:"refund_amount"`
+	IsCaptured     bool     `json:"is_captured"`
+	BankResultCode string   `json:"bank_result_code"`
+	BankResultMsg  string   `json:"bank_result_msg"`
+	Currency       Currency `json:"currency"`
 }
 
 // refundPath defines the refund service path
@@ -64,5 +64,59 @@ func (c *client) Refund(ctx context.Context, params RefundParams) (*RefundRespon
 		return nil, fmt.Errorf("cannot unmarshal RefundResponse, err: %v", err)
 	}
 
-	return &resp, nil
+	return &resp, c.checkStatus(serviceRefund, params.RecTradeID, rawResp)
+}
+
+// RefundCancelParams defines the parameters for cancelling a refund which has not been settled yet
+// More details in: https://docs.tappaysdk.com/tutorial/zh/advanced.html#refund-cancel-api
+type RefundCancelParams struct {
+	RecTradeID string `json:"rec_trade_id"`
+	RefundID   string `json:"refund_id,omitempty"`
+}
+
+// MarshalMap implements the Marshaler interface
+func (r RefundCancelParams) MarshalMap() (map[string]interface{}, error) {
+	p, err := json.Marshal(&r)
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal RefundCancelParams: %v, err: %v", r, err)
+	}
+	var m map[string]interface{}
+	if err = json.Unmarshal(p, &m); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal RefundCancelParams into map, err: %v", err)
+	}
+
+	return m, nil
+}
+
+// RefundCancelResponse defines the API response returns by TapPay server after refund cancel request
+type RefundCancelResponse struct {
+	Status         int      `json:"status"`
+	Msg            string   `json:"msg"`
+	BankResultCode string   `json:"bank_result_code"`
+	BankResultMsg  string   `json:"bank_result_msg"`
+	Currency       Currency `json:"currency"`
+}
+
+// refundCancelPath defines the refund cancel service path
+const refundCancelPath = "/tpc/transaction/refund/cancel"
+
+// CancelRefund issues a refund cancel request according to input RefundCancelParams
+// and returns the parsed RefundCancelResponse from TapPay server
+func (c *client) CancelRefund(ctx context.Context, params RefundCancelParams) (*RefundCancelResponse, error) {
+	req, err := c.newRequest(ctx, http.MethodPost, serviceRefundCancel, params)
+	if err != nil {
+		return nil, err
+	}
+
+	rawResp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp RefundCancelResponse
+	if err = json.Unmarshal(rawResp, &resp); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal RefundCancelResponse, err: %v", err)
+	}
+
+	return &resp, c.checkStatus(serviceRefundCancel, params.RecTradeID, rawResp)
 }
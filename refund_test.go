@@ -73,3 +73,43 @@ func setupPayment(t *testing.T, c *client) (string, error) {
 
 	return resp.RecTradeID, err
 }
+
+func TestCancelRefund(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		params     RefundCancelParams
+		respBody   string
+		wantStatus int
+	}{
+		{
+			name:       "Given unsettled refund returns success",
+			params:     RefundCancelParams{RecTradeID: "D20200101abc", RefundID: "R20200101abc"},
+			respBody:   `{"status":0,"msg":"Success","bank_result_code":"00","bank_result_msg":"","currency":"TWD"}`,
+			wantStatus: 0,
+		},
+		{
+			name:       "Given invalid rec_trade_id returns error status",
+			params:     RefundCancelParams{RecTradeID: "Invalid_trade_id"},
+			respBody:   `{"status":11000,"msg":"Invalid rec_trade_id"}`,
+			wantStatus: 11000,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var params map[string]interface{}
+			client := newTestClient(t, cannedResponse(t, refundCancelPath, &params, tc.respBody))
+			resp, err := client.CancelRefund(context.Background(), tc.params)
+			if err != nil {
+				t.Fatalf("unexpected refund cancel error, err: %v", err)
+			}
+			if params["rec_trade_id"] != tc.params.RecTradeID {
+				t.Errorf("expected rec_trade_id: %s in request, got: %v", tc.params.RecTradeID, params["rec_trade_id"])
+			}
+			if _, ok := params["refund_id"]; ok != (tc.params.RefundID != "") {
+				t.Errorf("unexpected refund_id in request: %v", params["refund_id"])
+			}
+			if tc.wantStatus != resp.Status {
+				t.Errorf("expect status: %d, got %d", tc.wantStatus, resp.Status)
+			}
+		})
+	}
+}
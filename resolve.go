@@ -0,0 +1,294 @@
+package tappay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// the default timeout and polling interval of querying the records to resolve the payment outcome
+const (
+	defaultResolveTimeout         = 30 * time.Second
+	defaultResolvePollInterval    = 500 * time.Millisecond
+	defaultResolveMaxPollInterval = 4 * time.Second
+)
+
+// NotChargedError is the error denoting the payment is definitely not charged, i.e. the trade of the
+// order number or bank transaction id made after the request has failed, and there is neither
+// successful nor pending trade
+type NotChargedError struct {
+	OrderNumber       string
+	BankTransactionID string
+
+	// Record is the latest failed trade of the payment
+	Record *Record
+
+	// Err is the error of the payment request which makes its outcome ambiguous, if any
+	Err error
+}
+
+// Error implements the error interface
+func (e *NotChargedError) Error() string {
+	msg := fmt.Sprintf("tappay: payment is not charged, order_number: %q, bank_transaction_id: %q", e.OrderNumber, e.BankTransactionID)
+	if e.Record != nil {
+		msg += fmt.Sprintf(", rec_trade_id: %s, record_status: %v", e.Record.RecTradeID, e.Record.RecordStatus)
+	}
+	if e.Err != nil {
+		msg += fmt.Sprintf(", err: %v", e.Err)
+	}
+	return msg
+}
+
+// Unwrap returns the error of the payment request
+func (e *NotChargedError) Unwrap() error {
+	return e.Err
+}
+
+// OutcomeUnknownError is the error denoting the outcome of the payment is still unknown, i.e. there is
+// no trade of the order number or bank transaction id, or the trade is still pending, until the timeout.
+// The payment may still be charged later, thus it should not be retried as if not charged.
+type OutcomeUnknownError struct {
+	OrderNumber       string
+	BankTransactionID string
+
+	// Record is the latest pending trade of the payment, or nil if TapPay server has no trade of it yet
+	Record *Record
+
+	// QueryErr is the last error of querying the records, if any
+	QueryErr error
+
+	// Err is the error of the payment request which makes its outcome ambiguous, if any
+	Err error
+}
+
+// Error implements the error interface
+func (e *OutcomeUnknownError) Error() string {
+	msg := fmt.Sprintf("tappay: payment outcome is unknown, order_number: %q, bank_transaction_id: %q", e.OrderNumber, e.BankTransactionID)
+	if e.Record != nil {
+		msg += fmt.Sprintf(", rec_trade_id: %s, record_status: %v", e.Record.RecTradeID, e.Record.RecordStatus)
+	}
+	if e.QueryErr != nil {
+		msg += fmt.Sprintf(", last query err: %v", e.QueryErr)
+	}
+	if e.Err != nil {
+		msg += fmt.Sprintf(", err: %v", e.Err)
+	}
+	return msg
+}
+
+// Unwrap returns the error of the payment request
+func (e *OutcomeUnknownError) Unwrap() error {
+	return e.Err
+}
+
+// WithOutcomeResolver returns a clientOption to resolve the outcome of PayByPrime and PayByToken
+// when the request fails in transport, e.g. timeout, network error or an HTTPError of server error,
+// and the params carry the order number or bank transaction id. The request canceled by the caller is
+// not resolved. The trades of the same amount and currency made since the request are looked up, see
+// ResolvePaymentOutcome. The records are queried with backoff within timeout, or 30 seconds if timeout
+// is not positive. The response is recovered from the successful trade if found, otherwise a
+// NotChargedError or OutcomeUnknownError wrapping the original error is returned.
+func WithOutcomeResolver(timeout time.Duration) clientOption {
+	return func(c *client) {
+		c.resolveOutcome = true
+		c.resolveTimeout = timeout
+		if timeout <= 0 {
+			c.resolveTimeout = defaultResolveTimeout
+		}
+	}
+}
+
+// resolveClockSkew is the tolerance of the clock difference between the client and TapPay server
+// when looking up the trades made since the payment request
+const resolveClockSkew = 5 * time.Second
+
+// PaymentOutcomeParams defines the payment whose outcome is resolved by ResolvePaymentOutcome
+type PaymentOutcomeParams struct {
+	OrderNumber       string
+	BankTransactionID string
+
+	// Amount is the amount of the payment, the trades of other amounts are ignored if it is positive
+	Amount int
+
+	// Currency is the currency of the payment, which is TWD if empty
+	Currency Currency
+
+	// RequestTime is the time the payment request is sent. The trades made before it are ignored, so
+	// that the earlier trades of the same order number are not taken as the outcome of the payment.
+	// All the trades are considered if it is zero.
+	RequestTime time.Time
+}
+
+// ResolvePaymentOutcome looks up the trade of the payment identified by the order number and/or
+// the bank transaction id, together with its amount, currency and request time, querying the records
+// with backoff while there is no trade or the trade is pending, until ctx is done, or 30 seconds if
+// ctx has no deadline.
+// It returns the successful trade, preferring the latest one, a NotChargedError if the trades made
+// after the request time have failed, or an OutcomeUnknownError if the outcome is not resolved in time.
+func (c *client) ResolvePaymentOutcome(ctx context.Context, payment PaymentOutcomeParams) (*Record, error) {
+	if payment.OrderNumber == "" && payment.BankTransactionID == "" {
+		return nil, fmt.Errorf("tappay: cannot resolve payment outcome without order_number or bank_transaction_id")
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, defaultResolveTimeout)
+		defer cancel()
+	}
+
+	currency := normalizeCurrency(payment.Currency)
+	// the failed trades are definitive only if made after the request, while the other trades are
+	// looked up with the tolerance of clock skew since they are not mistaken for not charged
+	var since, failedSince int64
+	if !payment.RequestTime.IsZero() {
+		since = timeToMillis(payment.RequestTime.Add(-resolveClockSkew))
+		failedSince = timeToMillis(payment.RequestTime)
+	}
+	filters := &RecordFilters{
+		OrderNumber:       payment.OrderNumber,
+		BankTransactionID: payment.BankTransactionID,
+		Currency:          currency,
+	}
+	if since > 0 {
+		filters.Time = &RecordFilterTime{StartTime: since}
+	}
+	if payment.Amount > 0 {
+		filters.Amount = &RecordFilterAmount{LowerLimit: payment.Amount, UpperLimit: payment.Amount}
+	}
+	params := RecordParams{
+		Filters: filters,
+		OrderBy: &RecordSort{Attribute: "time", IsDescending: true},
+	}
+	// matches reports whether the record is a trade of the payment made since the time in milliseconds
+	matches := func(r *Record, since int64) bool {
+		if payment.Amount > 0 && r.Amount != payment.Amount {
+			return false
+		}
+		return normalizeCurrency(r.Currency) == currency && r.Time >= since
+	}
+
+	unknown := &OutcomeUnknownError{OrderNumber: payment.OrderNumber, BankTransactionID: payment.BankTransactionID}
+	interval := c.resolvePollInterval
+	if interval <= 0 {
+		interval = defaultResolvePollInterval
+	}
+	for {
+		resp, err := c.recordsPage(ctx, params)
+		if err != nil {
+			unknown.QueryErr = err
+		} else {
+			unknown.QueryErr, unknown.Record = nil, nil
+			var failed *Record
+			for i := range resp.TradeRecords {
+				record := &resp.TradeRecords[i]
+				if !matches(record, since) {
+					continue
+				}
+				switch {
+				case isPaid(record.RecordStatus):
+					return record, nil
+				case record.RecordStatus == RecordStatusPending:
+					if unknown.Record == nil {
+						unknown.Record = record
+					}
+				case failed == nil && matches(record, failedSince):
+					failed = record
+				}
+			}
+			if failed != nil && unknown.Record == nil {
+				return nil, &NotChargedError{OrderNumber: payment.OrderNumber, BankTransactionID: payment.BankTransactionID, Record: failed}
+			}
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, unknown
+		case <-timer.C:
+		}
+		if interval *= 2; interval > defaultResolveMaxPollInterval {
+			interval = defaultResolveMaxPollInterval
+		}
+	}
+}
+
+// resolvePayment resolves the outcome of the payment request of svc failing with cause, if the client
+// is created with WithOutcomeResolver option and the payment may have been processed by TapPay server.
+// The payment canceled by the caller is not resolved. It returns the response recovered from the trade,
+// or the error of the payment.
+func (c *client) resolvePayment(ctx context.Context, svc service, payment PaymentOutcomeParams, cause error) (*PaymentResponse, error) {
+	if !c.resolveOutcome || (payment.OrderNumber == "" && payment.BankTransactionID == "") {
+		return nil, cause
+	}
+	if errors.Is(ctx.Err(), context.Canceled) || !isAmbiguous(cause) {
+		return nil, cause
+	}
+
+	// the context of the payment may have expired, which is the cause of the ambiguity
+	ctx, cancel := context.WithTimeout(context.Background(), c.resolveTimeout)
+	defer cancel()
+
+	record, err := c.ResolvePaymentOutcome(ctx, payment)
+	switch err := err.(type) {
+	case nil:
+	case *NotChargedError:
+		err.Err = cause
+		return nil, err
+	case *OutcomeUnknownError:
+		err.Err = cause
+		return nil, err
+	default:
+		return nil, fmt.Errorf("cannot resolve the outcome of %s, err: %v, cause: %w", svc, err, cause)
+	}
+	resp := recoveredPaymentResponse(record)
+	return &resp, nil
+}
+
+// isAmbiguous reports whether the request failing with err may have been processed by TapPay server,
+// i.e. it fails in transport or times out, or TapPay server responds a server error. The request
+// canceled by the caller, the response exceeding the maximum size and the client errors are not.
+func isAmbiguous(err error) bool {
+	var httpErr *HTTPError
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, ErrResponseTooLarge):
+		return false
+	case errors.As(err, &httpErr):
+		return httpErr.StatusCode >= http.StatusInternalServerError
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, io.ErrUnexpectedEOF):
+		return true
+	}
+	return errors.As(err, &netErr)
+}
+
+// recoveredPaymentResponse returns the PaymentResponse of the successful record, marked as Recovered.
+// The fields absent from the record, e.g. acquirer and payment url, are left empty.
+func recoveredPaymentResponse(r *Record) PaymentResponse {
+	return PaymentResponse{
+		Status:                int(StatusSuccess),
+		RecTradeID:            r.RecTradeID,
+		BankTransactionID:     r.BankTransactionID,
+		AuthCode:              r.AuthCode,
+		Amount:                r.Amount,
+		Currency:              r.Currency,
+		CardInfo:              PaymentCardInfo{RecordCardInfo: r.CardInfo},
+		OrderNumber:           r.OrderNumber,
+		TransactionTimeMillis: r.Time,
+		BankTransactionTime: PaymentBankTransactionTime{
+			StartTimeMillis: strconv.FormatInt(r.BankTransactionStartMillis, 10),
+			EndTimeMillis:   strconv.FormatInt(r.BankTransactionEndMillis, 10),
+		},
+		BankResultCode:        r.BankResultCode,
+		BankResultMsg:         r.BankResultMsg,
+		InstalmentInfo:        r.InstalmentInfo,
+		RedeemInfo:            PaymentRedeemInfo{RecordRedeemInfo: r.RedeemInfo},
+		CardIdentifier:        r.CardIdentifier,
+		MerchantReferenceInfo: r.MerchantReferenceInfo,
+		Recovered:             true,
+	}
+}
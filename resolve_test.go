@@ -0,0 +1,309 @@
+package tappay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testResolvedRecordBody is a canned record response of a successful and a failed trade of the same order.
+// The times {recent} and {stale} are replaced with the time after and long before the payment request.
+const testResolvedRecordBody = `{
+	"status": 0,
+	"msg": "Success",
+	"trade_records": [
+		{"rec_trade_id": "D20200101def", "order_number": "order-1", "amount": 100, "currency": "TWD", "record_status": -1, "bank_result_code": "05", "time": {recent}},
+		{"rec_trade_id": "D20200101abc", "order_number": "order-1", "bank_transaction_id": "TP20200101abc", "amount": 100, "currency": "TWD", "record_status": 1, "auth_code": "123456", "time": {recent}}
+	]
+}`
+
+// testPendingRecordBody is a canned record response of a pending 3D secure trade
+const testPendingRecordBody = `{"status": 0, "trade_records": [{"rec_trade_id": "D20200101ghi", "order_number": "order-1", "amount": 100, "record_status": 4, "time": {recent}}]}`
+
+// testRecordTimes returns the replacer of the times in the canned record responses
+func testRecordTimes() *strings.Replacer {
+	recent := time.Now().Add(time.Second).UnixNano() / int64(time.Millisecond)
+	return strings.NewReplacer("{recent}", strconv.FormatInt(recent, 10), "{stale}", "1577836800000")
+}
+
+func TestResolvePayment(t *testing.T) {
+	for _, tc := range []struct {
+		name            string
+		options         []clientOption
+		orderNumber     string
+		recordBodies    []string
+		wantRecTradeID  string
+		wantNotCharged  bool
+		wantUnknown     bool
+		wantRecord      bool
+		wantQueryCount  int
+		wantMoreQueries bool
+	}{
+		{
+			name:           "Given successful trade recovers the response",
+			options:        []clientOption{WithOutcomeResolver(time.Second)},
+			orderNumber:    "order-1",
+			recordBodies:   []string{testResolvedRecordBody},
+			wantRecTradeID: "D20200101abc",
+			wantQueryCount: 1,
+		},
+		{
+			name:           "Given trade appearing on later query recovers the response",
+			options:        []clientOption{WithOutcomeResolver(time.Second)},
+			orderNumber:    "order-1",
+			recordBodies:   []string{`{"status": 2, "msg": "No record"}`, testPendingRecordBody, testResolvedRecordBody},
+			wantRecTradeID: "D20200101abc",
+			wantQueryCount: 3,
+		},
+		{
+			name:            "Given no trade returns OutcomeUnknownError after timeout",
+			options:         []clientOption{WithOutcomeResolver(50 * time.Millisecond)},
+			orderNumber:     "order-1",
+			recordBodies:    []string{`{"status": 2, "msg": "No record"}`},
+			wantUnknown:     true,
+			wantMoreQueries: true,
+		},
+		{
+			name:            "Given pending trade returns OutcomeUnknownError with the record after timeout",
+			options:         []clientOption{WithOutcomeResolver(50 * time.Millisecond)},
+			orderNumber:     "order-1",
+			recordBodies:    []string{testPendingRecordBody},
+			wantUnknown:     true,
+			wantRecord:      true,
+			wantMoreQueries: true,
+		},
+		{
+			name:           "Given failed trade only returns NotChargedError with the record",
+			options:        []clientOption{WithOutcomeResolver(time.Second)},
+			orderNumber:    "order-1",
+			recordBodies:   []string{`{"status": 0, "trade_records": [{"rec_trade_id": "D20200101def", "order_number": "order-1", "amount": 100, "record_status": -1, "time": {recent}}]}`},
+			wantNotCharged: true,
+			wantRecord:     true,
+			wantQueryCount: 1,
+		},
+		{
+			name:            "Given stale failed trade of the order returns OutcomeUnknownError after timeout",
+			options:         []clientOption{WithOutcomeResolver(50 * time.Millisecond)},
+			orderNumber:     "order-1",
+			recordBodies:    []string{`{"status": 0, "trade_records": [{"rec_trade_id": "D20200101def", "order_number": "order-1", "amount": 100, "record_status": -1, "time": {stale}}]}`},
+			wantUnknown:     true,
+			wantMoreQueries: true,
+		},
+		{
+			name:            "Given stale successful trade of the order returns OutcomeUnknownError after timeout",
+			options:         []clientOption{WithOutcomeResolver(50 * time.Millisecond)},
+			orderNumber:     "order-1",
+			recordBodies:    []string{`{"status": 0, "trade_records": [{"rec_trade_id": "D20200101abc", "order_number": "order-1", "amount": 100, "record_status": 1, "time": {stale}}]}`},
+			wantUnknown:     true,
+			wantMoreQueries: true,
+		},
+		{
+			name:            "Given successful trade of another amount returns OutcomeUnknownError after timeout",
+			options:         []clientOption{WithOutcomeResolver(50 * time.Millisecond)},
+			orderNumber:     "order-1",
+			recordBodies:    []string{`{"status": 0, "trade_records": [{"rec_trade_id": "D20200101abc", "order_number": "order-1", "amount": 200, "record_status": 1, "time": {recent}}]}`},
+			wantUnknown:     true,
+			wantMoreQueries: true,
+		},
+		{
+			name:            "Given failed query returns OutcomeUnknownError after timeout",
+			options:         []clientOption{WithOutcomeResolver(50 * time.Millisecond)},
+			orderNumber:     "order-1",
+			recordBodies:    []string{`{"status": 88, "msg": "Internal error"}`},
+			wantUnknown:     true,
+			wantMoreQueries: true,
+		},
+		{
+			name:           "Given no order number returns the original error",
+			options:        []clientOption{WithOutcomeResolver(time.Second)},
+			wantQueryCount: 0,
+		},
+		{
+			name:           "Given resolver disabled returns the original error",
+			orderNumber:    "order-1",
+			recordBodies:   []string{testResolvedRecordBody},
+			wantQueryCount: 0,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var queries int
+			recordTimes := testRecordTimes()
+			mux := http.NewServeMux()
+			mux.HandleFunc(payByPrimePath, func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/html")
+				w.WriteHeader(http.StatusGatewayTimeout)
+				io.WriteString(w, "<html>504 Gateway Time-out</html>")
+			})
+			mux.HandleFunc(recordPath, func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				body := tc.recordBodies[len(tc.recordBodies)-1]
+				if queries < len(tc.recordBodies) {
+					body = tc.recordBodies[queries]
+				}
+				queries++
+				mu.Unlock()
+
+				var params RecordParams
+				if err := json.NewDecoder(r.Body).Decode(&params); err != nil || params.Filters == nil || params.Filters.OrderNumber != tc.orderNumber {
+					t.Errorf("expected query by order_number %s, got: %+v, err: %v", tc.orderNumber, params.Filters, err)
+				} else if f := params.Filters; f.Time == nil || f.Time.StartTime == 0 || f.Amount == nil || f.Amount.LowerLimit != 100 || f.Amount.UpperLimit != 100 || f.Currency != CurrencyTWD {
+					t.Errorf("expected query since the request of 100 TWD, got: %+v", f)
+				}
+				w.Header().Set("Content-Type", "application/json")
+				io.WriteString(w, recordTimes.Replace(body))
+			})
+			cli := newTestClient(t, mux, tc.options...)
+			cli.resolvePollInterval = time.Millisecond
+
+			params := validPaymentPrimeParams()
+			params.OrderNumber = tc.orderNumber
+			resp, err := cli.PayByPrime(context.Background(), params)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if tc.wantMoreQueries && queries < 2 {
+				t.Errorf("expected records queried again, got: %d queries", queries)
+			}
+			if !tc.wantMoreQueries && queries != tc.wantQueryCount {
+				t.Errorf("expected record queries: %d, got: %d", tc.wantQueryCount, queries)
+			}
+
+			if tc.wantRecTradeID != "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if !resp.Recovered || resp.RecTradeID != tc.wantRecTradeID || resp.StatusCode() != StatusSuccess || resp.AuthCode != "123456" || resp.TransactionTimeMillis == 0 {
+					t.Errorf("expected response recovered from %s, got: %+v", tc.wantRecTradeID, resp)
+				}
+				return
+			}
+
+			var httpErr *HTTPError
+			if !errors.As(err, &httpErr) {
+				t.Errorf("expected error wrapping HTTPError, got: %v", err)
+			}
+			var notCharged *NotChargedError
+			if errors.As(err, &notCharged) != tc.wantNotCharged {
+				t.Fatalf("expected NotChargedError: %t, got: %v", tc.wantNotCharged, err)
+			}
+			var unknown *OutcomeUnknownError
+			if errors.As(err, &unknown) != tc.wantUnknown {
+				t.Fatalf("expected OutcomeUnknownError: %t, got: %v", tc.wantUnknown, err)
+			}
+			switch {
+			case tc.wantNotCharged && (notCharged.Record != nil) != tc.wantRecord:
+				t.Errorf("expected record: %t, got: %+v", tc.wantRecord, notCharged.Record)
+			case tc.wantUnknown && (unknown.Record != nil) != tc.wantRecord:
+				t.Errorf("expected record: %t, got: %+v", tc.wantRecord, unknown.Record)
+			}
+		})
+	}
+}
+
+func TestPayByTokenResolvePayment(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc(payByTokenPath, func(w http.ResponseWriter, r *http.Request) {
+		// the client times out before the response
+		time.Sleep(100 * time.Millisecond)
+	})
+	mux.HandleFunc(recordPath, cannedResponse(t, recordPath, nil, testRecordTimes().Replace(testResolvedRecordBody)))
+	cli := newTestClient(t, mux, WithOutcomeResolver(0), WithHTTPClient(&http.Client{Timeout: 10 * time.Millisecond}))
+
+	resp, err := cli.PayByToken(context.Background(), PaymentTokenParams{
+		CardKey:           "card_key",
+		CardToken:         "card_token",
+		MerchantID:        "GlobalTesting_CTBC",
+		Amount:            100,
+		Currency:          CurrencyTWD,
+		Details:           "test-tappay-go-package",
+		BankTransactionID: "TP20200101abc",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Recovered || resp.RecTradeID != "D20200101abc" {
+		t.Errorf("expected response recovered from D20200101abc, got: %+v", resp)
+	}
+}
+
+func TestResolvePaymentSkipped(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		options []clientOption
+		handler http.HandlerFunc
+		cancel  bool
+		wantErr func(error) bool
+	}{
+		{
+			name: "Given client error returns the HTTPError without resolving",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadRequest)
+			},
+			wantErr: func(err error) bool {
+				var httpErr *HTTPError
+				return errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusBadRequest
+			},
+		},
+		{
+			name:    "Given response exceeding maximum size returns ErrResponseTooLarge without resolving",
+			options: []clientOption{WithMaxResponseSize(8)},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				io.WriteString(w, `{"status": 0, "msg": "Success"}`)
+			},
+			wantErr: func(err error) bool { return err == ErrResponseTooLarge },
+		},
+		{
+			name: "Given request canceled by the caller returns the error without resolving",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				// the disconnection of the client is detected after the body is read
+				io.Copy(ioutil.Discard, r.Body)
+				<-r.Context().Done()
+			},
+			cancel:  true,
+			wantErr: func(err error) bool { return errors.Is(err, context.Canceled) },
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var queries int
+			mux := http.NewServeMux()
+			mux.HandleFunc(payByPrimePath, tc.handler)
+			mux.HandleFunc(recordPath, func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				queries++
+				mu.Unlock()
+				w.Header().Set("Content-Type", "application/json")
+				io.WriteString(w, testRecordTimes().Replace(testResolvedRecordBody))
+			})
+			cli := newTestClient(t, mux, append(tc.options, WithOutcomeResolver(time.Second))...)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tc.cancel {
+				time.AfterFunc(10*time.Millisecond, cancel)
+			}
+			params := validPaymentPrimeParams()
+			params.OrderNumber = "order-1"
+			_, err := cli.PayByPrime(ctx, params)
+			if !tc.wantErr(err) {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if queries != 0 {
+				t.Errorf("expected no record queries, got: %d", queries)
+			}
+		})
+	}
+}
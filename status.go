@@ -0,0 +1,147 @@
+package tappay
+
+import "fmt"
+
+// StatusCode denotes the `status` field in the responses of TapPay server
+// More details in: https://docs.tappaysdk.com/tutorial/zh/reference.html#response-code
+type StatusCode int
+
+// The catalog of commonly seen status codes. Codes not listed here are reported as unknown
+// by Description and are not classified by any of the classification helpers.
+const (
+	StatusSuccess                  StatusCode = 0
+	StatusNoRecord                 StatusCode = 2
+	StatusAuthenticationFailed     StatusCode = 4
+	StatusInternalError            StatusCode = 88
+	StatusInvalidPrime             StatusCode = 91
+	StatusInvalidArguments         StatusCode = 121
+	StatusGatewayTimeout           StatusCode = 421
+	StatusUnexpectedError          StatusCode = 915
+	StatusCardError                StatusCode = 10003
+	StatusBankSystemError          StatusCode = 10005
+	StatusDuplicateTransaction     StatusCode = 10006
+	StatusBankMerchantAccountError StatusCode = 10008
+	StatusAmountError              StatusCode = 10009
+	StatusDuplicateBankTxID        StatusCode = 10013
+	StatusBankError                StatusCode = 10023
+	StatusInvalidRecTradeID        StatusCode = 11000
+)
+
+// statusClass denotes the classification of a StatusCode
+type statusClass int
+
+const (
+	statusClassNone statusClass = iota
+	statusClassRetryable
+	statusClassDeclinedByBank
+	statusClassInvalidRequest
+	statusClassAuthError
+)
+
+// statusInfo defines the description and classification of a StatusCode in the catalog
+type statusInfo struct {
+	description string
+	class       statusClass
+}
+
+var statusCatalog = map[StatusCode]statusInfo{
+	StatusSuccess:                  {"Success", statusClassNone},
+	StatusNoRecord:                 {"No record in the requested page", statusClassNone},
+	StatusAuthenticationFailed:     {"Authentication failed, the partner key is invalid", statusClassAuthError},
+	StatusInternalError:            {"TapPay internal error", statusClassRetryable},
+	StatusInvalidPrime:             {"The prime is invalid or expired", statusClassInvalidRequest},
+	StatusInvalidArguments:         {"Invalid arguments", statusClassInvalidRequest},
+	StatusGatewayTimeout:           {"Gateway timeout", statusClassRetryable},
+	StatusUnexpectedError:          {"Unexpected error, contact TapPay for the transaction state", statusClassNone},
+	StatusCardError:                {"Card error", statusClassDeclinedByBank},
+	StatusBankSystemError:          {"Bank system error", statusClassRetryable},
+	StatusDuplicateTransaction:     {"Duplicate transaction", statusClassInvalidRequest},
+	StatusBankMerchantAccountError: {"Bank merchant account data error", statusClassAuthError},
+	StatusAmountError:              {"Amount error", statusClassInvalidRequest},
+	StatusDuplicateBankTxID:        {"Duplicate bank_transaction_id", statusClassInvalidRequest},
+	StatusBankError:                {"Bank error", statusClassDeclinedByBank},
+	StatusInvalidRecTradeID:        {"Invalid rec_trade_id", statusClassInvalidRequest},
+}
+
+// Description returns the human-readable description of the status code
+func (s StatusCode) Description() string {
+	if info, ok := statusCatalog[s]; ok {
+		return info.description
+	}
+	return "Unknown status"
+}
+
+// String implements the fmt.Stringer interface
+func (s StatusCode) String() string {
+	return fmt.Sprintf("%d (%s)", int(s), s.Description())
+}
+
+// IsSuccess reports whether the status code denotes a successful operation
+func (s StatusCode) IsSuccess() bool {
+	return s == StatusSuccess
+}
+
+// IsRetryable reports whether the operation failed temporarily and can be retried as is
+func (s StatusCode) IsRetryable() bool {
+	return statusCatalog[s].class == statusClassRetryable
+}
+
+// IsDeclinedByBank reports whether the transaction is declined by the issuer or acquirer bank
+func (s StatusCode) IsDeclinedByBank() bool {
+	return statusCatalog[s].class == statusClassDeclinedByBank
+}
+
+// IsInvalidRequest reports whether the request is rejected because of the invalid parameters
+func (s StatusCode) IsInvalidRequest() bool {
+	return statusCatalog[s].class == statusClassInvalidRequest
+}
+
+// IsAuthError reports whether the request is rejected because of the partner key or merchant settings
+func (s StatusCode) IsAuthError() bool {
+	return statusCatalog[s].class == statusClassAuthError
+}
+
+// StatusCode returns the status of the response as StatusCode
+func (r PaymentResponse) StatusCode() StatusCode {
+	return StatusCode(r.Status)
+}
+
+// StatusCode returns the status of the response as StatusCode
+func (r RefundResponse) StatusCode() StatusCode {
+	return StatusCode(r.Status)
+}
+
+// StatusCode returns the status of the response as StatusCode
+func (r RefundCancelResponse) StatusCode() StatusCode {
+	return StatusCode(r.Status)
+}
+
+// StatusCode returns the status of the response as StatusCode
+func (r RecordResponse) StatusCode() StatusCode {
+	return StatusCode(r.Status)
+}
+
+// StatusCode returns the status of the response as StatusCode
+func (r CaptureResponse) StatusCode() StatusCode {
+	return StatusCode(r.Status)
+}
+
+// StatusCode returns the status of the response as StatusCode
+func (r TradeHistoryResponse) StatusCode() StatusCode {
+	return StatusCode(r.Status)
+}
+
+// StatusCode returns the status of the response as StatusCode
+func (r BindCardResponse) StatusCode() StatusCode {
+	return StatusCode(r.Status)
+}
+
+// StatusCode returns the status of the response as StatusCode
+func (r RemoveCardResponse) StatusCode() StatusCode {
+	return StatusCode(r.Status)
+}
+
+// StatusCode returns the status of the error as StatusCode
+func (e *APIError) StatusCode() StatusCode {
+	return StatusCode(e.Status)
+}
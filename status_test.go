@@ -0,0 +1,70 @@
+package tappay
+
+import (
+	"context"
+	"testing"
+)
+
+func TestStatusCodeClassification(t *testing.T) {
+	for _, tc := range []struct {
+		status        StatusCode
+		wantRetryable bool
+		wantDeclined  bool
+		wantInvalid   bool
+		wantAuth      bool
+	}{
+		{status: StatusSuccess},
+		{status: StatusNoRecord},
+		{status: StatusGatewayTimeout, wantRetryable: true},
+		{status: StatusBankSystemError, wantRetryable: true},
+		{status: StatusCardError, wantDeclined: true},
+		{status: StatusInvalidRecTradeID, wantInvalid: true},
+		{status: StatusAuthenticationFailed, wantAuth: true},
+		{status: StatusCode(-42)},
+	} {
+		t.Run(tc.status.String(), func(t *testing.T) {
+			if got := tc.status.IsRetryable(); got != tc.wantRetryable {
+				t.Errorf("expected IsRetryable: %t, got: %t", tc.wantRetryable, got)
+			}
+			if got := tc.status.IsDeclinedByBank(); got != tc.wantDeclined {
+				t.Errorf("expected IsDeclinedByBank: %t, got: %t", tc.wantDeclined, got)
+			}
+			if got := tc.status.IsInvalidRequest(); got != tc.wantInvalid {
+				t.Errorf("expected IsInvalidRequest: %t, got: %t", tc.wantInvalid, got)
+			}
+			if got := tc.status.IsAuthError(); got != tc.wantAuth {
+				t.Errorf("expected IsAuthError: %t, got: %t", tc.wantAuth, got)
+			}
+		})
+	}
+}
+
+func TestStatusCodeOfResponses(t *testing.T) {
+	cli := newTestClient(t, cannedResponse(t, refundPath, nil, `{"status":11000,"msg":"Invalid rec_trade_id"}`))
+	refund, err := cli.Refund(context.Background(), RefundParams{RecTradeID: "Invalid_trade_id"})
+	if err != nil {
+		t.Fatalf("unexpected refund error, err: %v", err)
+	}
+	if refund.StatusCode() != StatusInvalidRecTradeID {
+		t.Errorf("expected status: %v, got: %v", StatusInvalidRecTradeID, refund.StatusCode())
+	}
+
+	cli = newTestClient(t, cannedResponse(t, payByPrimePath, nil, `{"status":10003,"msg":"Card Error"}`))
+	payment, err := cli.PayByPrime(context.Background(), PaymentPrimeParams{
+		Prime:      "prime",
+		MerchantID: "GlobalTesting_CTBC",
+		Amount:     100,
+		Details:    "test-tappay-go-package",
+		Cardholder: PaymentParamsCardholder{
+			PhoneNumber: "0912345678",
+			Name:        "tappay-go",
+			Email:       "tappaygo@example.com",
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected pay-by-prime error, err: %v", err)
+	}
+	if !payment.StatusCode().IsDeclinedByBank() {
+		t.Errorf("expected status %v declined by bank", payment.StatusCode())
+	}
+}
@@ -0,0 +1,298 @@
+package tappaytest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Redacted is the placeholder of the scrubbed values in the cassette
+const Redacted = "REDACTED"
+
+// scrubbedFields are the fields scrubbed wherever they are in the request and response bodies
+var scrubbedFields = map[string]bool{
+	"partner_key": true,
+	"card_key":    true,
+	"card_token":  true,
+}
+
+// scrubbedObjects are the objects whose string fields are all scrubbed, e.g. the PII of cardholder
+var scrubbedObjects = map[string]bool{
+	"cardholder": true,
+}
+
+// scrubbedHeaders are the request headers which are not recorded in the cassette
+var scrubbedHeaders = []string{"x-api-key", "Authorization", "Cookie"}
+
+// ErrNoInteraction is returned by Replayer when no recorded interaction matches the request
+var ErrNoInteraction = errors.New("tappaytest: no recorded interaction matches the request")
+
+// CassetteRequest defines the recorded request in the cassette
+type CassetteRequest struct {
+	Method string      `json:"method"`
+	Path   string      `json:"path"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body"`
+}
+
+// CassetteResponse defines the recorded response in the cassette
+type CassetteResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body"`
+}
+
+// Interaction defines a recorded request/response pair, which is a line in the cassette
+type Interaction struct {
+	Request  CassetteRequest  `json:"request"`
+	Response CassetteResponse `json:"response"`
+}
+
+// Recorder is a http.RoundTripper which records the request/response pairs into a JSONL cassette
+// with the partner key, card secrets and cardholder PII scrubbed. It is intended to be used
+// with tappay.WithHTTPClient
+//
+//	f, _ := os.Create("testdata/payment.jsonl")
+//	rec := tappaytest.NewRecorder(f, nil)
+//	cli, _ := tappay.NewClient(key, tappay.WithHTTPClient(&http.Client{Transport: rec}))
+type Recorder struct {
+	transport http.RoundTripper
+
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewRecorder returns a Recorder which writes the cassette into w and delegates the requests
+// to transport. http.DefaultTransport is used if transport is nil.
+func NewRecorder(w io.Writer, transport http.RoundTripper) *Recorder {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &Recorder{
+		transport: transport,
+		enc:       json.NewEncoder(w),
+	}
+}
+
+// RoundTrip implements the http.RoundTripper interface
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := readBody(&resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	header := req.Header.Clone()
+	for _, h := range scrubbedHeaders {
+		header.Del(h)
+	}
+	// the length is changed after scrubbing and is set again in replay
+	respHeader := resp.Header.Clone()
+	respHeader.Del("Content-Length")
+	interaction := Interaction{
+		Request: CassetteRequest{
+			Method: req.Method,
+			Path:   req.URL.Path,
+			Header: header,
+			Body:   scrub(reqBody),
+		},
+		Response: CassetteResponse{
+			StatusCode: resp.StatusCode,
+			Header:     respHeader,
+			Body:       scrub(respBody),
+		},
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.enc.Encode(interaction); err != nil {
+		return nil, fmt.Errorf("tappaytest: cannot write the interaction into cassette, err: %v", err)
+	}
+	return resp, nil
+}
+
+// Replayer is a http.RoundTripper which serves the responses recorded in a cassette by Recorder.
+// The request is matched with the first unused interaction of the same method, path and scrubbed body.
+// ErrNoInteraction is returned if none of the interactions matches.
+type Replayer struct {
+	ignoreFields []string
+
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// NewReplayer returns a Replayer serving the interactions in the cassette read from r.
+// The ignoreFields are the dot-separated paths of the request body fields excluded from matching,
+// e.g. `order_number` or `filters.time`, which are usually generated from the time of the test.
+func NewReplayer(r io.Reader, ignoreFields ...string) (*Replayer, error) {
+	var interactions []Interaction
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 10<<20)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var i Interaction
+		if err := json.Unmarshal(line, &i); err != nil {
+			return nil, fmt.Errorf("tappaytest: cannot unmarshal the interaction %d in cassette, err: %v", len(interactions)+1, err)
+		}
+		interactions = append(interactions, i)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("tappaytest: cannot read cassette, err: %v", err)
+	}
+
+	return &Replayer{
+		ignoreFields: ignoreFields,
+		interactions: interactions,
+		used:         make([]bool, len(interactions)),
+	}, nil
+}
+
+// RoundTrip implements the http.RoundTripper interface
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+	body := r.normalize(scrub(reqBody))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, interaction := range r.interactions {
+		if r.used[i] || interaction.Request.Method != req.Method || interaction.Request.Path != req.URL.Path {
+			continue
+		}
+		if r.normalize(interaction.Request.Body) != body {
+			continue
+		}
+		r.used[i] = true
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+			StatusCode:    interaction.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        interaction.Response.Header.Clone(),
+			Body:          ioutil.NopCloser(strings.NewReader(interaction.Response.Body)),
+			ContentLength: int64(len(interaction.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("%w: %s %s %s", ErrNoInteraction, req.Method, req.URL.Path, body)
+}
+
+// Unused returns the interactions which have not been replayed yet
+func (r *Replayer) Unused() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var unused []Interaction
+	for i, interaction := range r.interactions {
+		if !r.used[i] {
+			unused = append(unused, interaction)
+		}
+	}
+	return unused
+}
+
+// normalize removes the ignored fields from the JSON body and re-encodes it with sorted keys
+func (r *Replayer) normalize(body string) string {
+	v, ok := decodeJSON(body)
+	if !ok {
+		return body
+	}
+	for _, field := range r.ignoreFields {
+		deleteField(v, strings.Split(field, "."))
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+// deleteField deletes the field at the path of the JSON value v
+func deleteField(v interface{}, path []string) {
+	m, ok := v.(map[string]interface{})
+	if !ok || len(path) == 0 {
+		return
+	}
+	if len(path) == 1 {
+		delete(m, path[0])
+		return
+	}
+	deleteField(m[path[0]], path[1:])
+}
+
+// readBody reads the body and replaces it with a reader of the same content
+func readBody(body *io.ReadCloser) ([]byte, error) {
+	if *body == nil || *body == http.NoBody {
+		return nil, nil
+	}
+	b, err := ioutil.ReadAll(*body)
+	(*body).Close()
+	if err != nil {
+		return nil, err
+	}
+	*body = ioutil.NopCloser(bytes.NewReader(b))
+	return b, nil
+}
+
+// decodeJSON decodes the body as JSON value with numbers kept as json.Number
+func decodeJSON(body string) (interface{}, bool) {
+	dec := json.NewDecoder(strings.NewReader(body))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, false
+	}
+	return v, true
+}
+
+// scrub replaces the sensitive values in the JSON body with Redacted.
+// The body is returned as is if it is not JSON.
+func scrub(body []byte) string {
+	v, ok := decodeJSON(string(body))
+	if !ok {
+		return string(body)
+	}
+	b, _ := json.Marshal(scrubValue(v, false))
+	return string(b)
+}
+
+// scrubValue scrubs the JSON value v recursively. All the strings are scrubbed if all is true.
+func scrubValue(v interface{}, all bool) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, field := range v {
+			if _, ok := field.(string); ok && scrubbedFields[k] {
+				v[k] = Redacted
+				continue
+			}
+			v[k] = scrubValue(field, all || scrubbedObjects[k])
+		}
+	case []interface{}:
+		for i, elem := range v {
+			v[i] = scrubValue(elem, all)
+		}
+	case string:
+		if all && v != "" {
+			return Redacted
+		}
+	}
+	return v
+}
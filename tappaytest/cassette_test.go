@@ -0,0 +1,75 @@
+package tappaytest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/babygoat/tappay-go"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	srv := NewServer(testPartnerKey)
+	defer srv.Close()
+
+	var cassette bytes.Buffer
+	recorder := NewRecorder(&cassette, nil)
+	cli, _ := tappay.NewClient(testPartnerKey, tappay.WithServer(srv.URL), tappay.WithHTTPClient(&http.Client{Transport: recorder}))
+
+	params := validPaymentParams("order-recorded", 100)
+	params.Remember = true
+	recorded, err := cli.PayByPrime(context.Background(), params)
+	if err != nil {
+		t.Fatalf("unexpected pay-by-prime error, err: %v", err)
+	}
+	if _, err := cli.Refund(context.Background(), tappay.RefundParams{RecTradeID: recorded.RecTradeID, Amount: "40"}); err != nil {
+		t.Fatalf("unexpected refund error, err: %v", err)
+	}
+
+	for _, secret := range []string{testPartnerKey, "0912345678", "tappaygo@example.com", recorded.CardSecret.CardKey} {
+		if strings.Contains(cassette.String(), secret) {
+			t.Errorf("expected %q scrubbed from cassette:\n%s", secret, cassette.String())
+		}
+	}
+	if lines := strings.Count(cassette.String(), "\n"); lines != 2 {
+		t.Errorf("expected 2 interactions in cassette, got: %d", lines)
+	}
+
+	replayer, err := NewReplayer(strings.NewReader(cassette.String()), "order_number")
+	if err != nil {
+		t.Fatalf("cannot load cassette: %v", err)
+	}
+	// replays without the fake server and with a different partner key and order number
+	cli, _ = tappay.NewClient("another_key", tappay.WithServer("http://replay.invalid"), tappay.WithHTTPClient(&http.Client{Transport: replayer}))
+
+	params.OrderNumber = "order-replayed"
+	replayed, err := cli.PayByPrime(context.Background(), params)
+	if err != nil {
+		t.Fatalf("unexpected replayed pay-by-prime error, err: %v", err)
+	}
+	if replayed.RecTradeID != recorded.RecTradeID || replayed.CardSecret.CardKey != Redacted {
+		t.Errorf("expected replayed rec_trade_id: %s with redacted card key, got: %s, %s", recorded.RecTradeID, replayed.RecTradeID, replayed.CardSecret.CardKey)
+	}
+
+	_, err = cli.Refund(context.Background(), tappay.RefundParams{RecTradeID: recorded.RecTradeID, Amount: "60"})
+	if !errors.Is(err, ErrNoInteraction) {
+		t.Errorf("expected ErrNoInteraction for unmatched refund, got: %v", err)
+	}
+	refund, err := cli.Refund(context.Background(), tappay.RefundParams{RecTradeID: recorded.RecTradeID, Amount: "40"})
+	if err != nil {
+		t.Fatalf("unexpected replayed refund error, err: %v", err)
+	}
+	if refund.RefundAmount != 40 {
+		t.Errorf("expected replayed refund amount: 40, got: %d", refund.RefundAmount)
+	}
+
+	if unused := replayer.Unused(); len(unused) != 0 {
+		t.Errorf("expected all interactions replayed, got unused: %+v", unused)
+	}
+	if _, err := cli.Refund(context.Background(), tappay.RefundParams{RecTradeID: recorded.RecTradeID, Amount: "40"}); !errors.Is(err, ErrNoInteraction) {
+		t.Errorf("expected ErrNoInteraction for replayed interaction, got: %v", err)
+	}
+}
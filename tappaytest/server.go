@@ -0,0 +1,541 @@
+// Package tappaytest provides utilities for testing the integration with TapPay without network access.
+package tappaytest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/babygoat/tappay-go"
+)
+
+// the paths of the services served by Server
+const (
+	payByPrimePath = "/tpc/payment/pay-by-prime"
+	refundPath     = "/tpc/transaction/refund"
+	recordPath     = "/tpc/transaction/query"
+
+	// threeDomainSecurePath is the path prefix of the 3D secure page of the pending transactions
+	threeDomainSecurePath = "/3ds/"
+)
+
+// the primes which make the pay-by-prime operation of Server fail
+const (
+	// PrimeCardError is the prime which is declined with tappay.StatusCardError
+	PrimeCardError = "tappaytest_card_error"
+
+	// PrimeBankError is the prime which is declined with tappay.StatusBankError
+	PrimeBankError = "tappaytest_bank_error"
+)
+
+// the default and maximum number of records per page of the record query
+const (
+	defaultRecordsPerPage = 50
+	maxRecordsPerPage     = 200
+)
+
+// Server is a fake TapPay server which implements the pay-by-prime, refund and record query
+// services with in-memory state. It is intended to be used with tappay.WithServer.
+// The payment url of the 3D secure transaction completes it when visited, see CompleteThreeDomainSecure.
+//
+//	srv := tappaytest.NewServer("partner_key")
+//	defer srv.Close()
+//	cli, _ := tappay.NewClient("partner_key", tappay.WithServer(srv.URL))
+type Server struct {
+	*httptest.Server
+
+	// PartnerKey is the partner key accepted by the server
+	PartnerKey string
+
+	// Now returns the current time used as the transaction time. Defaults to time.Now
+	Now func() time.Time
+
+	mu sync.Mutex
+	// trades are the transactions ordered by creation
+	trades []*tappay.Record
+	seq    int
+
+	// redirects are the frontend redirect urls of the 3D secure transactions keyed by rec_trade_id
+	redirects map[string]string
+}
+
+// NewServer starts and returns a new Server which accepts the partnerKey.
+// The caller should call Close when finished, to shut it down.
+func NewServer(partnerKey string) *Server {
+	s := &Server{
+		PartnerKey: partnerKey,
+		Now:        time.Now,
+		redirects:  make(map[string]string),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(payByPrimePath, s.handle(s.payByPrime))
+	mux.HandleFunc(refundPath, s.handle(s.refund))
+	mux.HandleFunc(recordPath, s.handle(s.records))
+	mux.HandleFunc(threeDomainSecurePath, s.threeDomainSecure)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// Records returns a snapshot of all the transactions in the order of creation
+func (s *Server) Records() []tappay.Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records := make([]tappay.Record, 0, len(s.trades))
+	for _, t := range s.trades {
+		records = append(records, *t)
+	}
+	return records
+}
+
+// Record returns the transaction identified by recTradeID
+func (s *Server) Record(recTradeID string) (tappay.Record, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if t := s.find(recTradeID); t != nil {
+		return *t, true
+	}
+	return tappay.Record{}, false
+}
+
+// AddRecord seeds the server with the transaction. A rec_trade_id is generated if it is empty.
+func (s *Server) AddRecord(r tappay.Record) tappay.Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.RecTradeID == "" {
+		r.RecTradeID = s.nextID("D")
+	}
+	s.trades = append(s.trades, &r)
+	return r
+}
+
+// CompleteThreeDomainSecure completes the pending 3D secure transaction identified by recTradeID,
+// which is authorized and captured on success and fails otherwise
+func (s *Server) CompleteThreeDomainSecure(recTradeID string, success bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := s.find(recTradeID)
+	if t == nil {
+		return fmt.Errorf("tappaytest: transaction %s not found", recTradeID)
+	}
+	if t.RecordStatus != tappay.RecordStatusPending {
+		return fmt.Errorf("tappaytest: transaction %s is not pending, record_status: %v", recTradeID, t.RecordStatus)
+	}
+	if !success {
+		t.RecordStatus = tappay.RecordStatusError
+		t.BankResultCode, t.BankResultMsg = "3D", "3D secure authentication failed"
+		return nil
+	}
+	t.RecordStatus = tappay.RecordStatusOK
+	t.IsCaptured = true
+	t.CapMillis = millis(s.Now())
+	return nil
+}
+
+// threeDomainSecure serves the 3D secure page of the payment url, which completes the pending transaction
+// by CompleteThreeDomainSecure, failing it if the query `result` is `fail`. It redirects to the frontend
+// redirect url with the query rec_trade_id and status if the url is given in the payment, or responds
+// 200 otherwise.
+func (s *Server) threeDomainSecure(w http.ResponseWriter, r *http.Request) {
+	recTradeID := strings.TrimPrefix(r.URL.Path, threeDomainSecurePath)
+	success := r.URL.Query().Get("result") != "fail"
+	if _, ok := s.Record(recTradeID); !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if err := s.CompleteThreeDomainSecure(recTradeID, success); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	s.mu.Lock()
+	redirect := s.redirects[recTradeID]
+	s.mu.Unlock()
+	if redirect == "" {
+		io.WriteString(w, "3D secure completed")
+		return
+	}
+	status := tappay.StatusSuccess
+	if !success {
+		status = tappay.StatusCardError
+	}
+	u, err := url.Parse(redirect)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	q := u.Query()
+	q.Set("rec_trade_id", recTradeID)
+	q.Set("status", strconv.Itoa(int(status)))
+	u.RawQuery = q.Encode()
+	http.Redirect(w, r, u.String(), http.StatusFound)
+}
+
+// find returns the transaction identified by recTradeID, or nil if not found.
+// The caller must hold s.mu.
+func (s *Server) find(recTradeID string) *tappay.Record {
+	for _, t := range s.trades {
+		if t.RecTradeID == recTradeID {
+			return t
+		}
+	}
+	return nil
+}
+
+// nextID generates a unique identifier with the prefix. The caller must hold s.mu.
+func (s *Server) nextID(prefix string) string {
+	s.seq++
+	return fmt.Sprintf("%s%s%06d", prefix, s.Now().Format("20060102"), s.seq)
+}
+
+// statusResponse defines the response body with only status and msg
+type statusResponse struct {
+	Status int    `json:"status"`
+	Msg    string `json:"msg"`
+}
+
+// handle decodes the request body, verifies the partner key and writes the response returned by fn as JSON
+func (s *Server) handle(fn func(body []byte) interface{}) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var body json.RawMessage
+		var auth struct {
+			PartnerKey string `json:"partner_key"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeJSON(w, statusResponse{Status: int(tappay.StatusInvalidArguments), Msg: "Invalid JSON"})
+			return
+		}
+		if err := json.Unmarshal(body, &auth); err != nil {
+			writeJSON(w, statusResponse{Status: int(tappay.StatusInvalidArguments), Msg: "Invalid arguments"})
+			return
+		}
+		if auth.PartnerKey != s.PartnerKey || r.Header.Get("x-api-key") != s.PartnerKey {
+			writeJSON(w, statusResponse{Status: int(tappay.StatusAuthenticationFailed), Msg: "Authentication failed"})
+			return
+		}
+
+		s.mu.Lock()
+		resp := fn(body)
+		s.mu.Unlock()
+		writeJSON(w, resp)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// payByPrime implements the pay-by-prime service. The caller must hold s.mu.
+func (s *Server) payByPrime(body []byte) interface{} {
+	var params tappay.PaymentPrimeParams
+	if err := json.Unmarshal(body, &params); err != nil {
+		return statusResponse{Status: int(tappay.StatusInvalidArguments), Msg: "Invalid arguments"}
+	}
+	switch {
+	case params.Prime == "":
+		return statusResponse{Status: int(tappay.StatusInvalidArguments), Msg: "Invalid arguments : prime"}
+	case params.MerchantID == "" && params.MerchantGroupID == "":
+		return statusResponse{Status: int(tappay.StatusInvalidArguments), Msg: "Invalid arguments : merchant_id"}
+	case params.Amount <= 0:
+		return statusResponse{Status: int(tappay.StatusAmountError), Msg: "Amount Error"}
+	case params.ThreeDomainSecure && params.ResultUrl == nil:
+		return statusResponse{Status: int(tappay.StatusInvalidArguments), Msg: "Invalid arguments : result_url"}
+	}
+	if params.BankTransactionID != "" {
+		for _, t := range s.trades {
+			if t.BankTransactionID == params.BankTransactionID {
+				return statusResponse{Status: int(tappay.StatusDuplicateBankTxID), Msg: "Duplicate bank_transaction_id"}
+			}
+		}
+	}
+
+	now := s.Now()
+	currency := params.Currency
+	if currency == "" {
+		currency = tappay.DefaultCurrency
+	}
+	bankTransactionID := params.BankTransactionID
+	if bankTransactionID == "" {
+		bankTransactionID = s.nextID("TP")
+	}
+	record := &tappay.Record{
+		RecTradeID:                 s.nextID("D"),
+		AuthCode:                   "123456",
+		MerchantID:                 params.MerchantID,
+		Time:                       millis(now),
+		Amount:                     params.Amount,
+		OriginalAmount:             params.Amount,
+		RecordStatus:               tappay.RecordStatusOK,
+		BankTransactionID:          bankTransactionID,
+		BankTransactionStartMillis: millis(now),
+		BankTransactionEndMillis:   millis(now),
+		IsCaptured:                 true,
+		CapMillis:                  millis(now),
+		BankResultCode:             "00",
+		PartialCardNumber:          "424242******4242",
+		PaymentMethod:              tappay.PaymentMethodCreditCard,
+		Details:                    params.Details,
+		Cardholder: tappay.RecordCardholder{
+			Name:        params.Cardholder.Name,
+			PhoneNumber: params.Cardholder.PhoneNumber,
+			Email:       params.Cardholder.Email,
+		},
+		Currency:          currency,
+		ThreeDomainSecure: params.ThreeDomainSecure,
+		PayByInstalment:   params.Instalment > 0,
+		OrderNumber:       params.OrderNumber,
+		CardInfo: tappay.RecordCardInfo{
+			BinCode:     "424242",
+			LastFour:    "4242",
+			Issuer:      "TAPPAYTEST BANK",
+			Funding:     tappay.CardFundingCredit,
+			Type:        tappay.CardTypeVisa,
+			Country:     "TAIWAN, PROVINCE OF CHINA",
+			CountryCode: "TW",
+		},
+	}
+	if params.MerchandiseDetails != nil {
+		record.MerchandiseDetails = *params.MerchandiseDetails
+	}
+	if params.Instalment > 0 {
+		record.InstalmentInfo = tappay.RecordInstalmentInfo{
+			NumberOfInstalments: params.Instalment,
+			FirstPayment:        params.Amount/params.Instalment + params.Amount%params.Instalment,
+			EachPayment:         params.Amount / params.Instalment,
+		}
+	}
+
+	resp := tappay.PaymentPrimeResponse{
+		PaymentResponse: tappay.PaymentResponse{
+			Status:   int(tappay.StatusSuccess),
+			Msg:      "Success",
+			Currency: currency,
+		},
+	}
+	switch params.Prime {
+	case PrimeCardError:
+		record.RecordStatus = tappay.RecordStatusError
+		record.IsCaptured, record.CapMillis = false, 0
+		record.BankResultCode, record.BankResultMsg = "05", "Do not honour"
+		resp.Status, resp.Msg = int(tappay.StatusCardError), "Card Error"
+	case PrimeBankError:
+		record.RecordStatus = tappay.RecordStatusError
+		record.IsCaptured, record.CapMillis = false, 0
+		record.BankResultCode, record.BankResultMsg = "96", "System malfunction"
+		resp.Status, resp.Msg = int(tappay.StatusBankError), "Bank Error"
+	default:
+		switch {
+		case params.ThreeDomainSecure:
+			record.RecordStatus = tappay.RecordStatusPending
+			record.IsCaptured, record.CapMillis = false, 0
+			resp.PaymentUrl = s.URL + threeDomainSecurePath + record.RecTradeID
+			s.redirects[record.RecTradeID] = params.ResultUrl.FrontendRedirectUrl
+		case params.DelayCaptureInDays > 0:
+			record.RecordStatus = tappay.RecordStatusAuth
+			record.IsCaptured = false
+			record.CapMillis = millis(now.AddDate(0, 0, params.DelayCaptureInDays))
+		}
+		if params.Remember {
+			resp.CardSecret = tappay.PaymentCardSecret{
+				CardToken: s.nextID("token"),
+				CardKey:   s.nextID("key"),
+			}
+			record.CardIdentifier = s.nextID("identifier")
+		}
+	}
+	s.trades = append(s.trades, record)
+
+	resp.RecTradeID = record.RecTradeID
+	resp.BankTransactionID = record.BankTransactionID
+	resp.AuthCode = record.AuthCode
+	resp.Amount = record.Amount
+	resp.CardInfo = tappay.PaymentCardInfo{RecordCardInfo: record.CardInfo, ExpiryDate: "203012"}
+	resp.OrderNumber = record.OrderNumber
+	resp.Acquirer = "TW_TAPPAYTEST"
+	resp.TransactionTimeMillis = record.Time
+	resp.BankTransactionTime = tappay.PaymentBankTransactionTime{
+		StartTimeMillis: strconv.FormatInt(record.BankTransactionStartMillis, 10),
+		EndTimeMillis:   strconv.FormatInt(record.BankTransactionEndMillis, 10),
+	}
+	resp.BankResultCode = record.BankResultCode
+	resp.BankResultMsg = record.BankResultMsg
+	resp.InstalmentInfo = record.InstalmentInfo
+	resp.CardIdentifier = record.CardIdentifier
+	return resp
+}
+
+// refund implements the refund service. The caller must hold s.mu.
+func (s *Server) refund(body []byte) interface{} {
+	var params tappay.RefundParams
+	if err := json.Unmarshal(body, &params); err != nil {
+		return statusResponse{Status: int(tappay.StatusInvalidArguments), Msg: "Invalid arguments"}
+	}
+	record := s.find(params.RecTradeID)
+	if record == nil {
+		return statusResponse{Status: int(tappay.StatusInvalidRecTradeID), Msg: "Invalid rec_trade_id"}
+	}
+	switch record.RecordStatus {
+	case tappay.RecordStatusAuth, tappay.RecordStatusOK, tappay.RecordStatusPartialRefunded:
+	default:
+		return statusResponse{Status: int(tappay.StatusInvalidRecTradeID), Msg: "The transaction cannot be refunded"}
+	}
+
+	remaining := record.Amount - record.RefundedAmount
+	amount := remaining
+	if params.Amount != "" {
+		var err error
+		if amount, err = strconv.Atoi(params.Amount); err != nil || amount <= 0 {
+			return statusResponse{Status: int(tappay.StatusInvalidArguments), Msg: "Invalid arguments : amount"}
+		}
+	}
+	if amount > remaining {
+		return statusResponse{Status: int(tappay.StatusAmountError), Msg: "Amount Error"}
+	}
+
+	record.RefundedAmount += amount
+	if record.RefundedAmount == record.Amount {
+		record.RecordStatus = tappay.RecordStatusRefunded
+	} else {
+		record.RecordStatus = tappay.RecordStatusPartialRefunded
+	}
+	refundID := params.BankRefundID
+	if refundID == "" {
+		refundID = s.nextID("R")
+	}
+	return tappay.RefundResponse{
+		Status:         int(tappay.StatusSuccess),
+		Msg:            "Success",
+		RefundID:       refundID,
+		RefundAmount:   amount,
+		IsCaptured:     record.IsCaptured,
+		BankResultCode: "00",
+		Currency:       record.Currency,
+	}
+}
+
+// records implements the record query service. The caller must hold s.mu.
+func (s *Server) records(body []byte) interface{} {
+	var params tappay.RecordParams
+	if err := json.Unmarshal(body, &params); err != nil {
+		return statusResponse{Status: int(tappay.StatusInvalidArguments), Msg: "Invalid arguments"}
+	}
+	perPage := params.RecordsPerPage
+	if perPage == 0 {
+		perPage = defaultRecordsPerPage
+	}
+	if perPage < 0 || perPage > maxRecordsPerPage || params.Page < 0 {
+		return statusResponse{Status: int(tappay.StatusInvalidArguments), Msg: "Invalid arguments : records_per_page"}
+	}
+
+	var matched []tappay.Record
+	for _, t := range s.trades {
+		if matchFilters(t, params.Filters) {
+			matched = append(matched, *t)
+		}
+	}
+	sortRecords(matched, params.OrderBy)
+
+	resp := tappay.RecordResponse{
+		Status:               int(tappay.StatusSuccess),
+		Msg:                  "Success",
+		RecordsPerPage:       perPage,
+		Page:                 params.Page,
+		TotalPageCount:       (len(matched) + perPage - 1) / perPage,
+		NumberOfTransactions: int64(len(matched)),
+		TradeRecords:         []tappay.Record{},
+	}
+	start := params.Page * perPage
+	if start >= len(matched) {
+		resp.Status, resp.Msg = int(tappay.StatusNoRecord), "No record"
+		return resp
+	}
+	end := start + perPage
+	if end > len(matched) {
+		end = len(matched)
+	}
+	resp.TradeRecords = matched[start:end]
+	return resp
+}
+
+// matchFilters reports whether the record satisfies all the filters
+func matchFilters(r *tappay.Record, f *tappay.RecordFilters) bool {
+	if f == nil {
+		return true
+	}
+	if f.Time != nil {
+		if (f.Time.StartTime != 0 && r.Time < f.Time.StartTime) || (f.Time.EndTime != 0 && r.Time > f.Time.EndTime) {
+			return false
+		}
+	}
+	if f.Amount != nil {
+		if (f.Amount.LowerLimit != 0 && r.Amount < f.Amount.LowerLimit) || (f.Amount.UpperLimit != 0 && r.Amount > f.Amount.UpperLimit) {
+			return false
+		}
+	}
+	if f.Cardholder != nil {
+		if (f.Cardholder.Name != "" && r.Cardholder.Name != f.Cardholder.Name) ||
+			(f.Cardholder.PhoneNumber != "" && r.Cardholder.PhoneNumber != f.Cardholder.PhoneNumber) ||
+			(f.Cardholder.Email != "" && r.Cardholder.Email != f.Cardholder.Email) {
+			return false
+		}
+	}
+	if len(f.MerchantID) > 0 {
+		found := false
+		for _, id := range f.MerchantID {
+			found = found || id == r.MerchantID
+		}
+		if !found {
+			return false
+		}
+	}
+	if f.RecordStatus != nil && *f.RecordStatus != r.RecordStatus {
+		return false
+	}
+	return (f.RecTradeID == "" || f.RecTradeID == r.RecTradeID) &&
+		(f.OrderNumber == "" || f.OrderNumber == r.OrderNumber) &&
+		(f.BankTransactionID == "" || f.BankTransactionID == r.BankTransactionID) &&
+		(f.Currency == "" || f.Currency == r.Currency)
+}
+
+// sortRecords sorts the records w.r.t the order, which defaults to descending transaction time
+func sortRecords(records []tappay.Record, order *tappay.RecordSort) {
+	attribute, descending := "time", true
+	if order != nil {
+		if order.Attribute != "" {
+			attribute = order.Attribute
+		}
+		descending = order.IsDescending
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		a, b := records[i], records[j]
+		if descending {
+			a, b = b, a
+		}
+		if attribute == "amount" && a.Amount != b.Amount {
+			return a.Amount < b.Amount
+		}
+		return a.Time < b.Time
+	})
+}
+
+// millis returns the unix time of t in milliseconds
+func millis(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
@@ -0,0 +1,410 @@
+package tappaytest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/babygoat/tappay-go"
+)
+
+const testPartnerKey = "partner_tappaytest"
+
+func payByPrime(t *testing.T, srv *Server, params tappay.PaymentPrimeParams) *tappay.PaymentPrimeResponse {
+	t.Helper()
+
+	cli, _ := tappay.NewClient(testPartnerKey, tappay.WithServer(srv.URL))
+	resp, err := cli.PayByPrime(context.Background(), params)
+	if err != nil {
+		t.Fatalf("unexpected pay-by-prime error, err: %v", err)
+	}
+	return resp
+}
+
+func validPaymentParams(orderNumber string, amount int) tappay.PaymentPrimeParams {
+	return tappay.PaymentPrimeParams{
+		Prime:       "test_3a2fb2b7e892b914a03c95dd4dd5dc7970c908df67a49527c0a648b2bc9",
+		MerchantID:  "GlobalTesting_CTBC",
+		Amount:      amount,
+		OrderNumber: orderNumber,
+		Details:     "test-tappay-go-package",
+		Cardholder: tappay.PaymentParamsCardholder{
+			PhoneNumber: "0912345678",
+			Name:        "tappay-go",
+			Email:       "tappaygo@example.com",
+		},
+	}
+}
+
+func TestPayByPrime(t *testing.T) {
+	for _, tc := range []struct {
+		name             string
+		partnerKey       string
+		params           func() tappay.PaymentPrimeParams
+		wantStatus       tappay.StatusCode
+		wantRecordStatus tappay.RecordStatus
+	}{
+		{
+			name:             "Given valid params returns success and captured record",
+			partnerKey:       testPartnerKey,
+			params:           func() tappay.PaymentPrimeParams { return validPaymentParams("order-1", 100) },
+			wantStatus:       tappay.StatusSuccess,
+			wantRecordStatus: tappay.RecordStatusOK,
+		},
+		{
+			name:       "Given wrong partner key returns authentication failed",
+			partnerKey: "wrong_key",
+			params:     func() tappay.PaymentPrimeParams { return validPaymentParams("order-1", 100) },
+			wantStatus: tappay.StatusAuthenticationFailed,
+		},
+		{
+			name:       "Given empty prime returns invalid arguments",
+			partnerKey: testPartnerKey,
+			params: func() tappay.PaymentPrimeParams {
+				p := validPaymentParams("order-1", 100)
+				p.Prime = ""
+				return p
+			},
+			wantStatus: tappay.StatusInvalidArguments,
+		},
+		{
+			name:       "Given card error prime returns card error and failed record",
+			partnerKey: testPartnerKey,
+			params: func() tappay.PaymentPrimeParams {
+				p := validPaymentParams("order-1", 100)
+				p.Prime = PrimeCardError
+				return p
+			},
+			wantStatus:       tappay.StatusCardError,
+			wantRecordStatus: tappay.RecordStatusError,
+		},
+		{
+			name:       "Given delayed capture returns success and authorized record",
+			partnerKey: testPartnerKey,
+			params: func() tappay.PaymentPrimeParams {
+				p := validPaymentParams("order-1", 100)
+				p.DelayCaptureInDays = 3
+				return p
+			},
+			wantStatus:       tappay.StatusSuccess,
+			wantRecordStatus: tappay.RecordStatusAuth,
+		},
+		{
+			name:       "Given 3D secure returns payment url and pending record",
+			partnerKey: testPartnerKey,
+			params: func() tappay.PaymentPrimeParams {
+				p := validPaymentParams("order-1", 100)
+				p.ThreeDomainSecure = true
+				p.ResultUrl = &tappay.PaymentParamsResultUrl{
+					FrontendRedirectUrl: "https://example.com/redirect",
+					BackendNotifyUrl:    "https://example.com/notify",
+				}
+				return p
+			},
+			wantStatus:       tappay.StatusSuccess,
+			wantRecordStatus: tappay.RecordStatusPending,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := NewServer(testPartnerKey)
+			defer srv.Close()
+
+			// skips the client-side validation to verify the server-side one
+			cli, _ := tappay.NewClient(tc.partnerKey, tappay.WithServer(srv.URL), tappay.WithoutValidation())
+			resp, err := cli.PayByPrime(context.Background(), tc.params())
+			if err != nil {
+				t.Fatalf("unexpected pay-by-prime error, err: %v", err)
+			}
+			if resp.StatusCode() != tc.wantStatus {
+				t.Errorf("expected status: %v, got: %v", tc.wantStatus, resp.StatusCode())
+			}
+			if resp.RecTradeID == "" {
+				if len(srv.Records()) != 0 {
+					t.Errorf("expected no record, got: %+v", srv.Records())
+				}
+				return
+			}
+			record, ok := srv.Record(resp.RecTradeID)
+			if !ok {
+				t.Fatalf("expected record %s created", resp.RecTradeID)
+			}
+			if record.RecordStatus != tc.wantRecordStatus {
+				t.Errorf("expected record status: %d, got: %d", tc.wantRecordStatus, record.RecordStatus)
+			}
+			if (record.RecordStatus == tappay.RecordStatusPending) != (resp.PaymentUrl != "") {
+				t.Errorf("unexpected payment url: %q", resp.PaymentUrl)
+			}
+		})
+	}
+}
+
+func TestCompleteThreeDomainSecure(t *testing.T) {
+	srv := NewServer(testPartnerKey)
+	defer srv.Close()
+
+	params := validPaymentParams("order-1", 100)
+	params.ThreeDomainSecure = true
+	params.ResultUrl = &tappay.PaymentParamsResultUrl{FrontendRedirectUrl: "https://example.com/redirect", BackendNotifyUrl: "https://example.com/notify"}
+	resp := payByPrime(t, srv, params)
+
+	if err := srv.CompleteThreeDomainSecure(resp.RecTradeID, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if record, _ := srv.Record(resp.RecTradeID); record.RecordStatus != tappay.RecordStatusOK || !record.IsCaptured {
+		t.Errorf("expected captured record, got status: %d, is_captured: %t", record.RecordStatus, record.IsCaptured)
+	}
+	if err := srv.CompleteThreeDomainSecure(resp.RecTradeID, true); err == nil {
+		t.Errorf("expected error completing a completed transaction")
+	}
+}
+
+func TestThreeDomainSecurePaymentURL(t *testing.T) {
+	srv := NewServer(testPartnerKey)
+	defer srv.Close()
+	noRedirect := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+
+	for _, tc := range []struct {
+		name             string
+		orderNumber      string
+		query            string
+		wantRecordStatus tappay.RecordStatus
+		wantStatus       string
+	}{
+		{name: "Given payment url completes the transaction", orderNumber: "order-3ds-1", wantRecordStatus: tappay.RecordStatusOK, wantStatus: "0"},
+		{name: "Given payment url with failure fails the transaction", orderNumber: "order-3ds-2", query: "?result=fail", wantRecordStatus: tappay.RecordStatusError, wantStatus: "10003"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			params := validPaymentParams(tc.orderNumber, 100)
+			params.ThreeDomainSecure = true
+			params.ResultUrl = &tappay.PaymentParamsResultUrl{FrontendRedirectUrl: "https://example.com/redirect?cart=1", BackendNotifyUrl: "https://example.com/notify"}
+			resp := payByPrime(t, srv, params)
+
+			httpResp, err := noRedirect.Get(resp.PaymentUrl + tc.query)
+			if err != nil {
+				t.Fatalf("cannot visit payment url: %v", err)
+			}
+			httpResp.Body.Close()
+			if httpResp.StatusCode != http.StatusFound {
+				t.Fatalf("expected status code: %d, got: %d", http.StatusFound, httpResp.StatusCode)
+			}
+			location, _ := url.Parse(httpResp.Header.Get("Location"))
+			if q := location.Query(); location.Host != "example.com" || q.Get("cart") != "1" || q.Get("rec_trade_id") != resp.RecTradeID || q.Get("status") != tc.wantStatus {
+				t.Errorf("unexpected redirect location: %s", location)
+			}
+			if record, _ := srv.Record(resp.RecTradeID); record.RecordStatus != tc.wantRecordStatus {
+				t.Errorf("expected record status: %v, got: %v", tc.wantRecordStatus, record.RecordStatus)
+			}
+
+			httpResp, err = noRedirect.Get(resp.PaymentUrl)
+			if err != nil {
+				t.Fatalf("cannot visit payment url: %v", err)
+			}
+			httpResp.Body.Close()
+			if httpResp.StatusCode != http.StatusConflict {
+				t.Errorf("expected status code of completed transaction: %d, got: %d", http.StatusConflict, httpResp.StatusCode)
+			}
+		})
+	}
+
+	httpResp, err := http.Get(srv.URL + "/3ds/unknown")
+	if err != nil {
+		t.Fatalf("cannot visit payment url: %v", err)
+	}
+	httpResp.Body.Close()
+	if httpResp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected status code of unknown transaction: %d, got: %d", http.StatusNotFound, httpResp.StatusCode)
+	}
+}
+
+func TestRefund(t *testing.T) {
+	for _, tc := range []struct {
+		name               string
+		amounts            []string
+		wantStatus         []tappay.StatusCode
+		wantRecordStatus   tappay.RecordStatus
+		wantRefundedAmount int
+	}{
+		{
+			name:               "Given no amount refunds the transaction fully",
+			amounts:            []string{""},
+			wantStatus:         []tappay.StatusCode{tappay.StatusSuccess},
+			wantRecordStatus:   tappay.RecordStatusRefunded,
+			wantRefundedAmount: 100,
+		},
+		{
+			name:               "Given partial amount refunds the transaction partially",
+			amounts:            []string{"30"},
+			wantStatus:         []tappay.StatusCode{tappay.StatusSuccess},
+			wantRecordStatus:   tappay.RecordStatusPartialRefunded,
+			wantRefundedAmount: 30,
+		},
+		{
+			name:               "Given partial amounts summing to the amount refunds the transaction fully",
+			amounts:            []string{"30", "70"},
+			wantStatus:         []tappay.StatusCode{tappay.StatusSuccess, tappay.StatusSuccess},
+			wantRecordStatus:   tappay.RecordStatusRefunded,
+			wantRefundedAmount: 100,
+		},
+		{
+			name:               "Given amount exceeding the remaining amount returns amount error",
+			amounts:            []string{"30", "80"},
+			wantStatus:         []tappay.StatusCode{tappay.StatusSuccess, tappay.StatusAmountError},
+			wantRecordStatus:   tappay.RecordStatusPartialRefunded,
+			wantRefundedAmount: 30,
+		},
+		{
+			name:               "Given refunded transaction returns error",
+			amounts:            []string{"", ""},
+			wantStatus:         []tappay.StatusCode{tappay.StatusSuccess, tappay.StatusInvalidRecTradeID},
+			wantRecordStatus:   tappay.RecordStatusRefunded,
+			wantRefundedAmount: 100,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := NewServer(testPartnerKey)
+			defer srv.Close()
+			payment := payByPrime(t, srv, validPaymentParams("order-1", 100))
+
+			cli, _ := tappay.NewClient(testPartnerKey, tappay.WithServer(srv.URL))
+			for i, amount := range tc.amounts {
+				resp, err := cli.Refund(context.Background(), tappay.RefundParams{RecTradeID: payment.RecTradeID, Amount: amount})
+				if err != nil {
+					t.Fatalf("unexpected refund error, err: %v", err)
+				}
+				if resp.StatusCode() != tc.wantStatus[i] {
+					t.Errorf("expected status of refund %d: %v, got: %v", i, tc.wantStatus[i], resp.StatusCode())
+				}
+			}
+			record, _ := srv.Record(payment.RecTradeID)
+			if record.RecordStatus != tc.wantRecordStatus {
+				t.Errorf("expected record status: %d, got: %d", tc.wantRecordStatus, record.RecordStatus)
+			}
+			if record.RefundedAmount != tc.wantRefundedAmount {
+				t.Errorf("expected refunded amount: %d, got: %d", tc.wantRefundedAmount, record.RefundedAmount)
+			}
+		})
+	}
+}
+
+func TestRecords(t *testing.T) {
+	srv := NewServer(testPartnerKey)
+	defer srv.Close()
+
+	base := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		now := base.Add(time.Duration(i) * time.Hour)
+		srv.Now = func() time.Time { return now }
+		payByPrime(t, srv, validPaymentParams(fmt.Sprintf("order-%d", i), 100*(i+1)))
+	}
+
+	for _, tc := range []struct {
+		name             string
+		params           tappay.RecordParams
+		wantStatus       tappay.StatusCode
+		wantOrderNumbers []string
+		wantTotalPages   int
+	}{
+		{
+			name:             "Given empty params returns records in descending time",
+			wantStatus:       tappay.StatusSuccess,
+			wantOrderNumbers: []string{"order-4", "order-3", "order-2", "order-1", "order-0"},
+			wantTotalPages:   1,
+		},
+		{
+			name:             "Given records per page returns the requested page",
+			params:           tappay.RecordParams{RecordsPerPage: 2, Page: 1},
+			wantStatus:       tappay.StatusSuccess,
+			wantOrderNumbers: []string{"order-2", "order-1"},
+			wantTotalPages:   3,
+		},
+		{
+			name:           "Given page out of range returns no record",
+			params:         tappay.RecordParams{RecordsPerPage: 2, Page: 3},
+			wantStatus:     tappay.StatusNoRecord,
+			wantTotalPages: 3,
+		},
+		{
+			name:             "Given order number filter returns the matched record",
+			params:           tappay.RecordParams{Filters: &tappay.RecordFilters{OrderNumber: "order-3"}},
+			wantStatus:       tappay.StatusSuccess,
+			wantOrderNumbers: []string{"order-3"},
+			wantTotalPages:   1,
+		},
+		{
+			name: "Given time filter and ascending amount order returns records in range",
+			params: tappay.RecordParams{
+				Filters: &tappay.RecordFilters{Time: &tappay.RecordFilterTime{
+					StartTime: millis(base.Add(time.Hour)),
+					EndTime:   millis(base.Add(3 * time.Hour)),
+				}},
+				OrderBy: &tappay.RecordSort{Attribute: "amount"},
+			},
+			wantStatus:       tappay.StatusSuccess,
+			wantOrderNumbers: []string{"order-1", "order-2", "order-3"},
+			wantTotalPages:   1,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cli, _ := tappay.NewClient(testPartnerKey, tappay.WithServer(srv.URL))
+			resp, err := cli.Records(context.Background(), tc.params)
+			if err != nil {
+				t.Fatalf("unexpected record error, err: %v", err)
+			}
+			if resp.StatusCode() != tc.wantStatus {
+				t.Errorf("expected status: %v, got: %v", tc.wantStatus, resp.StatusCode())
+			}
+			if resp.TotalPageCount != tc.wantTotalPages {
+				t.Errorf("expected total page count: %d, got: %d", tc.wantTotalPages, resp.TotalPageCount)
+			}
+			var orderNumbers []string
+			for _, r := range resp.TradeRecords {
+				orderNumbers = append(orderNumbers, r.OrderNumber)
+			}
+			if fmt.Sprint(orderNumbers) != fmt.Sprint(tc.wantOrderNumbers) {
+				t.Errorf("expected order numbers: %v, got: %v", tc.wantOrderNumbers, orderNumbers)
+			}
+		})
+	}
+}
+
+func TestRecordsRecordStatusFilter(t *testing.T) {
+	srv := NewServer(testPartnerKey)
+	defer srv.Close()
+	srv.AddRecord(tappay.Record{RecTradeID: "D1", RecordStatus: tappay.RecordStatusAuth})
+	srv.AddRecord(tappay.Record{RecTradeID: "D2", RecordStatus: tappay.RecordStatusOK})
+	cli, _ := tappay.NewClient(testPartnerKey, tappay.WithServer(srv.URL))
+
+	auth, ok := tappay.RecordStatusAuth, tappay.RecordStatusOK
+	for _, tc := range []struct {
+		name   string
+		status *tappay.RecordStatus
+		want   []string
+	}{
+		{name: "Given auth filter returns the auth record", status: &auth, want: []string{"D1"}},
+		{name: "Given ok filter returns the ok record", status: &ok, want: []string{"D2"}},
+		{name: "Given no filter returns all records", want: []string{"D1", "D2"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := cli.Records(context.Background(), tappay.RecordParams{
+				Filters: &tappay.RecordFilters{RecordStatus: tc.status},
+				OrderBy: &tappay.RecordSort{Attribute: "amount"},
+			})
+			if err != nil {
+				t.Fatalf("unexpected record error: %v", err)
+			}
+			var got []string
+			for _, r := range resp.TradeRecords {
+				got = append(got, r.RecTradeID)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("expected records: %v, got: %v", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,263 @@
+package tappay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// the default polling and timeout of ThreeDomainSecureFlow
+const (
+	defaultThreeDomainSecurePollInterval    = 2 * time.Second
+	defaultThreeDomainSecureMaxPollInterval = 30 * time.Second
+	defaultThreeDomainSecureTimeout         = 10 * time.Minute
+)
+
+// ErrPaymentPending is returned by ThreeDomainSecureFlow.Wait when the payment is still pending
+// after the timeout, e.g. the cardholder leaves the 3D secure page without completing it
+var ErrPaymentPending = errors.New("tappay: payment is still pending")
+
+// ErrUnknownPayment is returned by ThreeDomainSecureFlow.Notify for the notification of
+// the payment which is not waiting for its outcome in the flow
+var ErrUnknownPayment = errors.New("tappay: notification of unknown payment")
+
+// ThreeDomainSecurePayment defines the 3D secure payment started by ThreeDomainSecureFlow.Start
+type ThreeDomainSecurePayment struct {
+	RecTradeID string
+
+	// PaymentURL is the url of the 3D secure page which the cardholder should be redirected to
+	PaymentURL string
+
+	// Response is the response of the pay-by-prime request
+	Response *PaymentPrimeResponse
+}
+
+// ThreeDomainSecureResult defines the final outcome of the 3D secure payment
+type ThreeDomainSecureResult struct {
+	RecTradeID string
+
+	// Paid reports whether the payment is authorized successfully according to the record
+	Paid bool
+
+	// Notification is the backend notification received before the outcome is resolved, if any
+	Notification *Notification
+
+	// Record is the record which resolves the outcome
+	Record *Record
+}
+
+// ThreeDomainSecureFlow is the helper wrapping the 3D secure payment flow. Start issues the pay-by-prime
+// request and returns the url of the 3D secure page. Wait resolves the final outcome by querying the
+// record with backoff until it leaves RecordStatusPending, and immediately when the backend notification
+// is passed to Notify. The forged notifications are thus harmless to the outcome.
+//
+// Since only the payments waiting in this process are known to Notify, the notification should be
+// persisted by the merchant's own callback first, and then passed to Notify
+//
+//	flow := cli.ThreeDomainSecureFlow()
+//	http.Handle("/tappay/notify", tappay.NewNotifyHandler(func(ctx context.Context, n *tappay.Notification) error {
+//		if err := orders.Update(ctx, n); err != nil {
+//			return err
+//		}
+//		if err := flow.Notify(ctx, n); err != nil && !errors.Is(err, tappay.ErrUnknownPayment) {
+//			return err
+//		}
+//		return nil
+//	}))
+//
+//	payment, err := flow.Start(ctx, params)
+//	// redirect the cardholder to payment.PaymentURL
+//	result, err := flow.Wait(ctx, payment)
+type ThreeDomainSecureFlow struct {
+	client *client
+
+	pollInterval    time.Duration
+	maxPollInterval time.Duration
+	timeout         time.Duration
+
+	mu sync.Mutex
+	// waiters are the channels receiving the notification of the started payments keyed by rec_trade_id
+	waiters map[string]chan *Notification
+}
+
+type threeDomainSecureOption func(*ThreeDomainSecureFlow)
+
+// ThreeDomainSecureFlow creates the ThreeDomainSecureFlow issuing the requests with the client
+func (c *client) ThreeDomainSecureFlow(options ...threeDomainSecureOption) *ThreeDomainSecureFlow {
+	f := &ThreeDomainSecureFlow{
+		client:          c,
+		pollInterval:    defaultThreeDomainSecurePollInterval,
+		maxPollInterval: defaultThreeDomainSecureMaxPollInterval,
+		timeout:         defaultThreeDomainSecureTimeout,
+		waiters:         make(map[string]chan *Notification),
+	}
+	for _, option := range options {
+		option(f)
+	}
+	return f
+}
+
+// WithPollInterval returns a threeDomainSecureOption to poll the record every interval at first,
+// doubling after each attempt up to max. Defaults to 2 seconds up to 30 seconds.
+func WithPollInterval(interval, max time.Duration) threeDomainSecureOption {
+	return func(f *ThreeDomainSecureFlow) {
+		f.pollInterval = interval
+		f.maxPollInterval = max
+	}
+}
+
+// WithOutcomeTimeout returns a threeDomainSecureOption to give up waiting for the outcome after timeout.
+// Defaults to 10 minutes.
+func WithOutcomeTimeout(timeout time.Duration) threeDomainSecureOption {
+	return func(f *ThreeDomainSecureFlow) {
+		f.timeout = timeout
+	}
+}
+
+// Start issues the pay-by-prime request with 3D secure enabled. An APIError is returned if the
+// payment is not accepted, regardless of WithStatusError option. Wait must be called with the
+// returned payment to resolve its outcome.
+func (f *ThreeDomainSecureFlow) Start(ctx context.Context, params PaymentPrimeParams) (*ThreeDomainSecurePayment, error) {
+	params.ThreeDomainSecure = true
+	resp, err := f.client.PayByPrime(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != StatusSuccess {
+		return nil, &APIError{
+			Status:         resp.Status,
+			Msg:            resp.Msg,
+			BankResultCode: resp.BankResultCode,
+			BankResultMsg:  resp.BankResultMsg,
+			RecTradeID:     resp.RecTradeID,
+			Service:        string(servicePayByPrime),
+		}
+	}
+	if resp.PaymentUrl == "" {
+		return nil, fmt.Errorf("tappay: pay-by-prime responds no payment_url, rec_trade_id: %s", resp.RecTradeID)
+	}
+
+	f.waiter(resp.RecTradeID)
+	return &ThreeDomainSecurePayment{
+		RecTradeID: resp.RecTradeID,
+		PaymentURL: resp.PaymentUrl,
+		Response:   resp,
+	}, nil
+}
+
+// Notify passes the backend notification to the payment waiting for it. It implements NotifyFunc
+// and is intended to be the callback, or called from the callback, of NotifyHandler.
+// ErrUnknownPayment is returned for the notification of the payment which is not waiting, e.g. after
+// Wait times out, the process restarts or the payment is started by another instance. Used as the
+// callback of NotifyHandler directly, the handler responds a server error so that TapPay server retries.
+func (f *ThreeDomainSecureFlow) Notify(ctx context.Context, n *Notification) error {
+	f.mu.Lock()
+	ch, ok := f.waiters[n.RecTradeID]
+	f.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("%w, rec_trade_id: %s", ErrUnknownPayment, n.RecTradeID)
+	}
+
+	select {
+	case ch <- n:
+	default:
+		// the payment has received a notification already
+	}
+	return nil
+}
+
+// Wait waits for the final outcome of the payment, until the record leaves RecordStatusPending.
+// The record is queried every poll interval, and immediately when the backend notification is received.
+// Since the notifications are not signed, the outcome is always confirmed by the record rather than
+// taken from the notification. ErrPaymentPending is returned after the timeout, wrapping the last error
+// of querying the record if any.
+func (f *ThreeDomainSecureFlow) Wait(ctx context.Context, payment *ThreeDomainSecurePayment) (*ThreeDomainSecureResult, error) {
+	ch := f.waiter(payment.RecTradeID)
+	defer f.release(payment.RecTradeID)
+
+	timeout := time.NewTimer(f.timeout)
+	defer timeout.Stop()
+	interval := f.pollInterval
+	poll := time.NewTimer(interval)
+	defer poll.Stop()
+
+	var notification *Notification
+	var lastErr error
+	// resolve returns the result if the record has left RecordStatusPending
+	resolve := func() *ThreeDomainSecureResult {
+		record, err := f.record(ctx, payment.RecTradeID)
+		if err != nil {
+			lastErr = err
+			return nil
+		}
+		if record == nil || record.RecordStatus == RecordStatusPending {
+			return nil
+		}
+		return &ThreeDomainSecureResult{
+			RecTradeID:   payment.RecTradeID,
+			Paid:         isPaid(record.RecordStatus),
+			Notification: notification,
+			Record:       record,
+		}
+	}
+	for {
+		select {
+		case notification = <-ch:
+			if result := resolve(); result != nil {
+				return result, nil
+			}
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timeout.C:
+			if lastErr != nil {
+				return nil, fmt.Errorf("%w, last query err: %v", ErrPaymentPending, lastErr)
+			}
+			return nil, ErrPaymentPending
+		case <-poll.C:
+			if result := resolve(); result != nil {
+				return result, nil
+			}
+			if interval *= 2; interval > f.maxPollInterval {
+				interval = f.maxPollInterval
+			}
+			poll.Reset(interval)
+		}
+	}
+}
+
+// waiter returns the channel receiving the notification of the payment, creating it if not exists
+func (f *ThreeDomainSecureFlow) waiter(recTradeID string) chan *Notification {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ch, ok := f.waiters[recTradeID]
+	if !ok {
+		ch = make(chan *Notification, 1)
+		f.waiters[recTradeID] = ch
+	}
+	return ch
+}
+
+// release removes the channel receiving the notification of the payment
+func (f *ThreeDomainSecureFlow) release(recTradeID string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.waiters, recTradeID)
+}
+
+// record returns the record of recTradeID, or nil if not found
+func (f *ThreeDomainSecureFlow) record(ctx context.Context, recTradeID string) (*Record, error) {
+	resp, err := f.client.recordsPage(ctx, RecordParams{Filters: &RecordFilters{RecTradeID: recTradeID}})
+	if err != nil {
+		return nil, err
+	}
+	for i := range resp.TradeRecords {
+		if resp.TradeRecords[i].RecTradeID == recTradeID {
+			return &resp.TradeRecords[i], nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,208 @@
+package tappay_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/babygoat/tappay-go"
+	"github.com/babygoat/tappay-go/tappaytest"
+)
+
+// threeDomainSecureParams returns the params of the 3D secure payment accepted by tappaytest.Server
+func threeDomainSecureParams(prime string) tappay.PaymentPrimeParams {
+	return tappay.PaymentPrimeParams{
+		Prime:       prime,
+		MerchantID:  "GlobalTesting_CTBC",
+		Amount:      100,
+		Details:     "test-tappay-go-package",
+		OrderNumber: "order-3ds",
+		Cardholder: tappay.PaymentParamsCardholder{
+			PhoneNumber: "0912345678",
+			Name:        "tappay-go",
+			Email:       "tappaygo@example.com",
+		},
+		ResultUrl: &tappay.PaymentParamsResultUrl{
+			FrontendRedirectUrl: "https://example.com/3ds/done",
+			BackendNotifyUrl:    "https://example.com/tappay/notify",
+		},
+	}
+}
+
+func TestThreeDomainSecureFlow(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		complete func(t *testing.T, srv *tappaytest.Server, flow *tappay.ThreeDomainSecureFlow, id string)
+		wantPaid bool
+		wantErr  error
+		wantBy   string
+	}{
+		{
+			name: "Given 3D secure succeeds returns paid by polling",
+			complete: func(t *testing.T, srv *tappaytest.Server, flow *tappay.ThreeDomainSecureFlow, id string) {
+				if err := srv.CompleteThreeDomainSecure(id, true); err != nil {
+					t.Errorf("cannot complete 3D secure: %v", err)
+				}
+			},
+			wantPaid: true,
+			wantBy:   "record",
+		},
+		{
+			name: "Given 3D secure fails returns unpaid by polling",
+			complete: func(t *testing.T, srv *tappaytest.Server, flow *tappay.ThreeDomainSecureFlow, id string) {
+				if err := srv.CompleteThreeDomainSecure(id, false); err != nil {
+					t.Errorf("cannot complete 3D secure: %v", err)
+				}
+			},
+			wantPaid: false,
+			wantBy:   "record",
+		},
+		{
+			name: "Given backend notification returns the outcome of the record",
+			complete: func(t *testing.T, srv *tappaytest.Server, flow *tappay.ThreeDomainSecureFlow, id string) {
+				if err := srv.CompleteThreeDomainSecure(id, true); err != nil {
+					t.Errorf("cannot complete 3D secure: %v", err)
+				}
+				if err := flow.Notify(context.Background(), &tappay.Notification{RecTradeID: "unknown", Status: int(tappay.StatusSuccess)}); !errors.Is(err, tappay.ErrUnknownPayment) {
+					t.Errorf("expected ErrUnknownPayment, got: %v", err)
+				}
+				if err := flow.Notify(context.Background(), &tappay.Notification{RecTradeID: id, Status: int(tappay.StatusSuccess)}); err != nil {
+					t.Errorf("unexpected notify error: %v", err)
+				}
+			},
+			wantPaid: true,
+			wantBy:   "notification",
+		},
+		{
+			name: "Given forged notification of pending trade returns ErrPaymentPending after timeout",
+			complete: func(t *testing.T, srv *tappaytest.Server, flow *tappay.ThreeDomainSecureFlow, id string) {
+				if err := flow.Notify(context.Background(), &tappay.Notification{RecTradeID: id, Status: int(tappay.StatusSuccess)}); err != nil {
+					t.Errorf("unexpected notify error: %v", err)
+				}
+			},
+			wantErr: tappay.ErrPaymentPending,
+			wantBy:  "notification",
+		},
+		{
+			name:     "Given no outcome returns ErrPaymentPending after timeout",
+			complete: func(t *testing.T, srv *tappaytest.Server, flow *tappay.ThreeDomainSecureFlow, id string) {},
+			wantErr:  tappay.ErrPaymentPending,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := tappaytest.NewServer(testPartnerKey)
+			defer srv.Close()
+			cli, err := tappay.NewClient(testPartnerKey, tappay.WithServer(srv.URL))
+			if err != nil {
+				t.Fatalf("cannot create client: %v", err)
+			}
+			// the notification tests poll rarely so that the record is queried on the notification
+			interval := 5 * time.Millisecond
+			if tc.wantBy == "notification" {
+				interval = time.Minute
+			}
+			flow := cli.ThreeDomainSecureFlow(tappay.WithPollInterval(interval, 4*interval), tappay.WithOutcomeTimeout(200*time.Millisecond))
+
+			payment, err := flow.Start(context.Background(), threeDomainSecureParams("test_prime"))
+			if err != nil {
+				t.Fatalf("unexpected start error: %v", err)
+			}
+			if payment.PaymentURL == "" || payment.RecTradeID == "" {
+				t.Fatalf("expected payment url and rec_trade_id, got: %+v", payment)
+			}
+
+			go tc.complete(t, srv, flow, payment.RecTradeID)
+			result, err := flow.Wait(context.Background(), payment)
+			if tc.wantErr != nil {
+				if !errors.Is(err, tc.wantErr) {
+					t.Errorf("expected error: %v, got: %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected wait error: %v", err)
+			}
+			if result.RecTradeID != payment.RecTradeID || result.Paid != tc.wantPaid {
+				t.Errorf("expected paid %t of %s, got: %+v", tc.wantPaid, payment.RecTradeID, result)
+			}
+			if by := map[bool]string{true: "notification", false: "record"}[result.Notification != nil]; by != tc.wantBy {
+				t.Errorf("expected resolved by %s, got: %s", tc.wantBy, by)
+			}
+			if result.Record == nil || result.Record.RecTradeID != payment.RecTradeID {
+				t.Errorf("expected record of %s, got: %+v", payment.RecTradeID, result.Record)
+			}
+		})
+	}
+}
+
+func TestThreeDomainSecureFlowStartDeclined(t *testing.T) {
+	srv := tappaytest.NewServer(testPartnerKey)
+	defer srv.Close()
+	cli, err := tappay.NewClient(testPartnerKey, tappay.WithServer(srv.URL))
+	if err != nil {
+		t.Fatalf("cannot create client: %v", err)
+	}
+
+	_, err = cli.ThreeDomainSecureFlow().Start(context.Background(), threeDomainSecureParams(tappaytest.PrimeCardError))
+	var apiErr *tappay.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode() != tappay.StatusCardError {
+		t.Errorf("expected APIError of card error, got: %v", err)
+	}
+}
+
+func TestThreeDomainSecureFlowContextCanceled(t *testing.T) {
+	srv := tappaytest.NewServer(testPartnerKey)
+	defer srv.Close()
+	cli, err := tappay.NewClient(testPartnerKey, tappay.WithServer(srv.URL))
+	if err != nil {
+		t.Fatalf("cannot create client: %v", err)
+	}
+	flow := cli.ThreeDomainSecureFlow(tappay.WithPollInterval(time.Millisecond, time.Millisecond))
+
+	payment, err := flow.Start(context.Background(), threeDomainSecureParams("test_prime"))
+	if err != nil {
+		t.Fatalf("unexpected start error: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := flow.Wait(ctx, payment); err != context.DeadlineExceeded {
+		t.Errorf("expected context.DeadlineExceeded, got: %v", err)
+	}
+}
+
+func TestThreeDomainSecureFlowNotifyAfterTimeout(t *testing.T) {
+	srv := tappaytest.NewServer(testPartnerKey)
+	defer srv.Close()
+	cli, err := tappay.NewClient(testPartnerKey, tappay.WithServer(srv.URL))
+	if err != nil {
+		t.Fatalf("cannot create client: %v", err)
+	}
+	flow := cli.ThreeDomainSecureFlow(tappay.WithPollInterval(time.Minute, time.Minute), tappay.WithOutcomeTimeout(10*time.Millisecond))
+
+	payment, err := flow.Start(context.Background(), threeDomainSecureParams("test_prime"))
+	if err != nil {
+		t.Fatalf("unexpected start error: %v", err)
+	}
+	if _, err := flow.Wait(context.Background(), payment); !errors.Is(err, tappay.ErrPaymentPending) {
+		t.Fatalf("expected ErrPaymentPending, got: %v", err)
+	}
+
+	var notifyErr error
+	h := tappay.NewNotifyHandler(flow.Notify, tappay.WithNotifyErrorHandler(func(r *http.Request, err error) {
+		notifyErr = err
+	}))
+	body := fmt.Sprintf(`{"rec_trade_id": %q, "status": 0, "amount": 100}`, payment.RecTradeID)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/notify", strings.NewReader(body)))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("expected status code for retry: %d, got: %d", http.StatusInternalServerError, w.Code)
+	}
+	if !errors.Is(notifyErr, tappay.ErrUnknownPayment) {
+		t.Errorf("expected ErrUnknownPayment, got: %v", notifyErr)
+	}
+}
@@ -0,0 +1,107 @@
+package tappay
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// TaipeiLocation is the time zone of Taiwan, i.e. Asia/Taipei, which has no daylight saving time.
+// The times returned by the accessors of this package are in this location.
+var TaipeiLocation = time.FixedZone("Asia/Taipei", 8*60*60)
+
+// millisToTime converts the unix time in milliseconds into time.Time in TaipeiLocation.
+// Zero is converted into the zero time.Time.
+func millisToTime(ms int64) time.Time {
+	if ms == 0 {
+		return time.Time{}
+	}
+	return time.Unix(ms/1000, (ms%1000)*int64(time.Millisecond)).In(TaipeiLocation)
+}
+
+// timeToMillis converts t into the unix time in milliseconds. The zero time.Time is converted into zero.
+func timeToMillis(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+// parseMillis parses the unix time in milliseconds of string type into time.Time in TaipeiLocation.
+// Empty string is parsed into the zero time.Time.
+func parseMillis(ms string) (time.Time, error) {
+	if ms == "" {
+		return time.Time{}, nil
+	}
+	v, err := strconv.ParseInt(ms, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("tappay: invalid milliseconds %q, err: %v", ms, err)
+	}
+	return millisToTime(v), nil
+}
+
+// NewRecordFilterTime returns the RecordFilterTime of the range [start, end].
+// The zero start or end leaves the bound unset.
+func NewRecordFilterTime(start, end time.Time) *RecordFilterTime {
+	return &RecordFilterTime{
+		StartTime: timeToMillis(start),
+		EndTime:   timeToMillis(end),
+	}
+}
+
+// NewRecordFilterDay returns the RecordFilterTime of the whole day in TaipeiLocation
+func NewRecordFilterDay(year int, month time.Month, day int) *RecordFilterTime {
+	start := time.Date(year, month, day, 0, 0, 0, 0, TaipeiLocation)
+	return NewRecordFilterTime(start, start.AddDate(0, 0, 1).Add(-time.Millisecond))
+}
+
+// Start returns the StartTime as time.Time in TaipeiLocation
+func (f RecordFilterTime) Start() time.Time {
+	return millisToTime(f.StartTime)
+}
+
+// End returns the EndTime as time.Time in TaipeiLocation
+func (f RecordFilterTime) End() time.Time {
+	return millisToTime(f.EndTime)
+}
+
+// TransactionTime returns the transaction time as time.Time in TaipeiLocation
+func (r PaymentResponse) TransactionTime() time.Time {
+	return millisToTime(r.TransactionTimeMillis)
+}
+
+// StartTime parses the start time of the bank transaction as time.Time in TaipeiLocation
+func (t PaymentBankTransactionTime) StartTime() (time.Time, error) {
+	return parseMillis(t.StartTimeMillis)
+}
+
+// EndTime parses the end time of the bank transaction as time.Time in TaipeiLocation
+func (t PaymentBankTransactionTime) EndTime() (time.Time, error) {
+	return parseMillis(t.EndTimeMillis)
+}
+
+// TransactionTime returns the transaction time as time.Time in TaipeiLocation
+func (r Record) TransactionTime() time.Time {
+	return millisToTime(r.Time)
+}
+
+// CapTime returns the capture time as time.Time in TaipeiLocation, which is the expected
+// capture time if the transaction is not captured yet
+func (r Record) CapTime() time.Time {
+	return millisToTime(r.CapMillis)
+}
+
+// BankTransactionStartTime returns the start time of the bank transaction as time.Time in TaipeiLocation
+func (r Record) BankTransactionStartTime() time.Time {
+	return millisToTime(r.BankTransactionStartMillis)
+}
+
+// BankTransactionEndTime returns the end time of the bank transaction as time.Time in TaipeiLocation
+func (r Record) BankTransactionEndTime() time.Time {
+	return millisToTime(r.BankTransactionEndMillis)
+}
+
+// Time returns the time of the action as time.Time in TaipeiLocation
+func (h TradeHistory) Time() time.Time {
+	return millisToTime(h.Millis)
+}
@@ -0,0 +1,47 @@
+package tappay
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecordTimeAccessors(t *testing.T) {
+	r := Record{Time: 1577896200123, CapMillis: 0}
+	want := time.Date(2020, 1, 2, 0, 30, 0, 123*int(time.Millisecond), TaipeiLocation)
+	if !r.TransactionTime().Equal(want) || r.TransactionTime().Location() != TaipeiLocation {
+		t.Errorf("expected transaction time: %v, got: %v", want, r.TransactionTime())
+	}
+	if !r.CapTime().IsZero() {
+		t.Errorf("expected zero cap time, got: %v", r.CapTime())
+	}
+}
+
+func TestPaymentBankTransactionTime(t *testing.T) {
+	bt := PaymentBankTransactionTime{StartTimeMillis: "1577896200123", EndTimeMillis: "invalid"}
+	start, err := bt.StartTime()
+	if err != nil || timeToMillis(start) != 1577896200123 {
+		t.Errorf("expected start time of 1577896200123 ms, got: %v, err: %v", start, err)
+	}
+	if _, err := bt.EndTime(); err == nil {
+		t.Errorf("expected error of invalid end time")
+	}
+	if end, err := (PaymentBankTransactionTime{}).EndTime(); err != nil || !end.IsZero() {
+		t.Errorf("expected zero end time, got: %v, err: %v", end, err)
+	}
+}
+
+func TestNewRecordFilterDay(t *testing.T) {
+	f := NewRecordFilterDay(2020, time.January, 2)
+	// 2020-01-02 00:00:00 +08:00 is 2020-01-01 16:00:00 UTC
+	if f.StartTime != 1577894400000 || f.EndTime != 1577980799999 {
+		t.Errorf("expected filter [1577894400000, 1577980799999], got: [%d, %d]", f.StartTime, f.EndTime)
+	}
+	if f.Start().Format("2006-01-02 15:04:05") != "2020-01-02 00:00:00" {
+		t.Errorf("expected start in Taipei time, got: %v", f.Start())
+	}
+
+	f = NewRecordFilterTime(time.Time{}, time.Unix(1, 0))
+	if f.StartTime != 0 || f.EndTime != 1000 {
+		t.Errorf("expected filter [0, 1000], got: [%d, %d]", f.StartTime, f.EndTime)
+	}
+}
@@ -0,0 +1,156 @@
+package tappay
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// the limits of the fields in the requests to TapPay server
+const (
+	maxDetailsLength     = 100
+	maxOrderNumberLength = 50
+	maxRecordsPerPage    = 200
+)
+
+// allowedInstalments are the number of instalments accepted by TapPay, where 0 denotes no instalment
+var allowedInstalments = map[int]bool{0: true, 3: true, 6: true, 12: true, 18: true, 24: true, 30: true}
+
+// ValidationError defines the violation of a field in the request
+type ValidationError struct {
+	// Field is the JSON path of the field, e.g. `cardholder.phone_number`
+	Field  string
+	Reason string
+}
+
+// Error implements the error interface
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Reason)
+}
+
+// ValidationErrors is the error returned by Validate with all the violations of the request
+type ValidationErrors []ValidationError
+
+// Error implements the error interface
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, v := range e {
+		msgs[i] = v.Error()
+	}
+	return "tappay: invalid request: " + strings.Join(msgs, "; ")
+}
+
+// validator is the interface implemented by the params which validate themselves before sending
+type validator interface {
+	Validate() error
+}
+
+// violations collects the ValidationError of a request
+type violations ValidationErrors
+
+func (v *violations) add(field string, format string, args ...interface{}) {
+	*v = append(*v, ValidationError{Field: field, Reason: fmt.Sprintf(format, args...)})
+}
+
+func (v *violations) required(field string, value string) {
+	if value == "" {
+		v.add(field, "is required")
+	}
+}
+
+func (v *violations) maxLength(field string, value string, max int) {
+	if n := utf8.RuneCountInString(value); n > max {
+		v.add(field, "exceeds %d characters", max)
+	}
+}
+
+func (v *violations) currency(field string, value Currency) {
+	if value != "" && !value.Valid() {
+		v.add(field, "unsupported currency %q", value)
+	}
+}
+
+// err returns the ValidationErrors, or nil if there is no violation
+func (v violations) err() error {
+	if len(v) == 0 {
+		return nil
+	}
+	return ValidationErrors(v)
+}
+
+// Validate checks the params before sending to TapPay server and returns ValidationErrors
+// with all the violations
+func (r PaymentPrimeParams) Validate() error {
+	var v violations
+	v.required("prime", r.Prime)
+	if r.MerchantID == "" && r.MerchantGroupID == "" {
+		v.add("merchant_id", "either merchant_id or merchant_group_id is required")
+	}
+	if r.Amount <= 0 {
+		v.add("amount", "must be positive")
+	}
+	v.currency("currency", r.Currency)
+	v.maxLength("order_number", r.OrderNumber, maxOrderNumberLength)
+	v.required("details", r.Details)
+	v.maxLength("details", r.Details, maxDetailsLength)
+	v.required("cardholder.phone_number", r.Cardholder.PhoneNumber)
+	v.required("cardholder.name", r.Cardholder.Name)
+	v.required("cardholder.email", r.Cardholder.Email)
+	if !allowedInstalments[r.Instalment] {
+		v.add("instalment", "must be one of 0, 3, 6, 12, 18, 24 and 30")
+	}
+	if r.DelayCaptureInDays < 0 {
+		v.add("delay_capture_in_days", "must not be negative")
+	}
+	if r.ThreeDomainSecure {
+		if r.ResultUrl == nil {
+			v.add("result_url", "is required with three_domain_secure")
+		} else {
+			v.required("result_url.frontend_redirect_url", r.ResultUrl.FrontendRedirectUrl)
+			v.required("result_url.backend_notify_url", r.ResultUrl.BackendNotifyUrl)
+		}
+	}
+	return v.err()
+}
+
+// Validate checks the params before sending to TapPay server and returns ValidationErrors
+// with all the violations
+func (r RefundParams) Validate() error {
+	var v violations
+	v.required("rec_trade_id", r.RecTradeID)
+	if r.Amount != "" {
+		if amount, err := strconv.Atoi(r.Amount); err != nil || amount <= 0 {
+			v.add("amount", "must be a positive integer")
+		}
+	}
+	return v.err()
+}
+
+// Validate checks the params before sending to TapPay server and returns ValidationErrors
+// with all the violations
+func (r RecordParams) Validate() error {
+	var v violations
+	if r.RecordsPerPage < 0 || r.RecordsPerPage > maxRecordsPerPage {
+		v.add("records_per_page", "must be between 0 and %d", maxRecordsPerPage)
+	}
+	if r.Page < 0 {
+		v.add("page", "must not be negative")
+	}
+	if r.OrderBy != nil && r.OrderBy.Attribute != "" && r.OrderBy.Attribute != "time" && r.OrderBy.Attribute != "amount" {
+		v.add("order_by.attribute", "must be either time or amount")
+	}
+	if f := r.Filters; f != nil {
+		if f.Time != nil && f.Time.StartTime != 0 && f.Time.EndTime != 0 && f.Time.StartTime > f.Time.EndTime {
+			v.add("filters.time", "start_time is after end_time")
+		}
+		if f.Amount != nil && f.Amount.LowerLimit != 0 && f.Amount.UpperLimit != 0 && f.Amount.LowerLimit > f.Amount.UpperLimit {
+			v.add("filters.amount", "lower_limit is greater than upper_limit")
+		}
+		if f.RecordStatus != nil && !f.RecordStatus.Valid() {
+			v.add("filters.record_status", "unknown record status %d", *f.RecordStatus)
+		}
+		v.currency("filters.currency", f.Currency)
+	}
+	return v.err()
+}
@@ -0,0 +1,170 @@
+package tappay
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func validPaymentPrimeParams() PaymentPrimeParams {
+	return PaymentPrimeParams{
+		Prime:      "test_3a2fb2b7e892b914a03c95dd4dd5dc7970c908df67a49527c0a648b2bc9",
+		MerchantID: "GlobalTesting_CTBC",
+		Amount:     100,
+		Details:    "test-tappay-go-package",
+		Cardholder: PaymentParamsCardholder{
+			PhoneNumber: "0912345678",
+			Name:        "tappay-go",
+			Email:       "tappaygo@example.com",
+		},
+	}
+}
+
+// fields returns the fields of the ValidationErrors
+func fields(t *testing.T, err error) []string {
+	t.Helper()
+
+	if err == nil {
+		return nil
+	}
+	var verrs ValidationErrors
+	if !errors.As(err, &verrs) {
+		t.Fatalf("expected ValidationErrors, got: %v", err)
+	}
+	var fs []string
+	for _, v := range verrs {
+		fs = append(fs, v.Field)
+	}
+	return fs
+}
+
+func TestPaymentPrimeParamsValidate(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		modify     func(*PaymentPrimeParams)
+		wantFields []string
+	}{
+		{
+			name:   "Given valid params returns no error",
+			modify: func(p *PaymentPrimeParams) {},
+		},
+		{
+			name:       "Given empty prime and phone number returns both violations",
+			modify:     func(p *PaymentPrimeParams) { p.Prime, p.Cardholder.PhoneNumber = "", "" },
+			wantFields: []string{"prime", "cardholder.phone_number"},
+		},
+		{
+			name:       "Given details over the length limit returns violation",
+			modify:     func(p *PaymentPrimeParams) { p.Details = strings.Repeat("茶", maxDetailsLength+1) },
+			wantFields: []string{"details"},
+		},
+		{
+			name:       "Given 3D secure without result url returns violation",
+			modify:     func(p *PaymentPrimeParams) { p.ThreeDomainSecure = true },
+			wantFields: []string{"result_url"},
+		},
+		{
+			name: "Given 3D secure with partial result url returns violation",
+			modify: func(p *PaymentPrimeParams) {
+				p.ThreeDomainSecure = true
+				p.ResultUrl = &PaymentParamsResultUrl{FrontendRedirectUrl: "https://example.com/redirect"}
+			},
+			wantFields: []string{"result_url.backend_notify_url"},
+		},
+		{
+			name:       "Given instalment outside allowed values returns violation",
+			modify:     func(p *PaymentPrimeParams) { p.Instalment = 5 },
+			wantFields: []string{"instalment"},
+		},
+		{
+			name:       "Given non-positive amount and unsupported currency returns violations",
+			modify:     func(p *PaymentPrimeParams) { p.Amount, p.Currency = 0, "EUR" },
+			wantFields: []string{"amount", "currency"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			params := validPaymentPrimeParams()
+			tc.modify(&params)
+			if got := fields(t, params.Validate()); !reflect.DeepEqual(got, tc.wantFields) {
+				t.Errorf("expected violations: %v, got: %v", tc.wantFields, got)
+			}
+		})
+	}
+}
+
+func TestRefundParamsValidate(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		params     RefundParams
+		wantFields []string
+	}{
+		{name: "Given full refund returns no error", params: RefundParams{RecTradeID: "D20200101abc"}},
+		{name: "Given partial refund returns no error", params: RefundParams{RecTradeID: "D20200101abc", Amount: "40"}},
+		{name: "Given empty rec_trade_id returns violation", params: RefundParams{}, wantFields: []string{"rec_trade_id"}},
+		{name: "Given non-numeric amount returns violation", params: RefundParams{RecTradeID: "D20200101abc", Amount: "40.5"}, wantFields: []string{"amount"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := fields(t, tc.params.Validate()); !reflect.DeepEqual(got, tc.wantFields) {
+				t.Errorf("expected violations: %v, got: %v", tc.wantFields, got)
+			}
+		})
+	}
+}
+
+func TestRecordParamsValidate(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		params     RecordParams
+		wantFields []string
+	}{
+		{name: "Given empty params returns no error", params: RecordParams{}},
+		{
+			name:       "Given records per page over the limit and negative page returns violations",
+			params:     RecordParams{RecordsPerPage: 201, Page: -1},
+			wantFields: []string{"records_per_page", "page"},
+		},
+		{
+			name:       "Given unknown sort attribute returns violation",
+			params:     RecordParams{OrderBy: &RecordSort{Attribute: "merchant"}},
+			wantFields: []string{"order_by.attribute"},
+		},
+		{
+			name:       "Given reversed time range returns violation",
+			params:     RecordParams{Filters: &RecordFilters{Time: &RecordFilterTime{StartTime: 2, EndTime: 1}}},
+			wantFields: []string{"filters.time"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := fields(t, tc.params.Validate()); !reflect.DeepEqual(got, tc.wantFields) {
+				t.Errorf("expected violations: %v, got: %v", tc.wantFields, got)
+			}
+		})
+	}
+}
+
+func TestClientValidation(t *testing.T) {
+	var requested bool
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		requested = true
+		cannedResponse(t, refundPath, nil, `{"status":121,"msg":"Invalid arguments"}`)(w, r)
+	}
+
+	cli := newTestClient(t, http.HandlerFunc(handler))
+	if _, err := cli.Refund(context.Background(), RefundParams{}); fields(t, err) == nil {
+		t.Errorf("expected validation error")
+	}
+	if requested {
+		t.Errorf("expected invalid params not sent")
+	}
+
+	cli = newTestClient(t, http.HandlerFunc(handler), WithoutValidation())
+	if _, err := cli.Refund(context.Background(), RefundParams{}); err != nil {
+		t.Errorf("unexpected error without validation: %v", err)
+	}
+	if !requested {
+		t.Errorf("expected params sent without validation")
+	}
+}